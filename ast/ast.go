@@ -164,6 +164,24 @@ func (i *IntegerLiteral) String() string {
 	return i.Token.Literal
 }
 
+// ----------------------------------------------------
+// FloatLiteral Struct
+// ----------------------------------------------------
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (f *FloatLiteral) expressionNode() {}
+
+func (f *FloatLiteral) TokenLiteral() string {
+	return f.Token.Literal
+}
+
+func (f *FloatLiteral) String() string {
+	return f.Token.Literal
+}
+
 // ----------------------------------------------------
 // Prefix Operator Expression
 // ----------------------------------------------------
@@ -238,6 +256,23 @@ func (b *Boolean) String() string {
 	return b.Token.Literal
 }
 
+// ----------------------------------------------------
+// Null Literal Struct
+// ----------------------------------------------------
+type NullLiteral struct {
+	Token token.Token // The `null` or `nil` token
+}
+
+func (nl *NullLiteral) expressionNode() {}
+
+func (nl *NullLiteral) TokenLiteral() string {
+	return nl.Token.Literal
+}
+
+func (nl *NullLiteral) String() string {
+	return nl.Token.Literal
+}
+
 // ----------------------------------------------------
 // If Statement Struct
 // ----------------------------------------------------
@@ -434,6 +469,45 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// ----------------------------------------------------
+// SliceExpression Struct
+// ----------------------------------------------------
+type SliceExpression struct {
+	Token token.Token // The `[` token
+	Left  Expression
+	Low   Expression // Optional, default to the start
+	High  Expression // Optional, default to the end
+}
+
+func (se *SliceExpression) expressionNode() {}
+
+func (se *SliceExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+
+	if se.Low != nil {
+		out.WriteString(se.Low.String())
+	}
+
+	out.WriteString(":")
+
+	if se.High != nil {
+		out.WriteString(se.High.String())
+	}
+
+	out.WriteString("]")
+	out.WriteString(")")
+
+	return out.String()
+}
+
 // ----------------------------------------------------
 // Assignment Struct
 // ----------------------------------------------------
@@ -454,7 +528,7 @@ func (ae *AssignmentExpression) String() string {
 
 	out.WriteString("(")
 	out.WriteString(ae.Name.String())
-	out.WriteString(" = ")
+	out.WriteString(" " + ae.Token.Literal + " ")
 
 	if ae.Value != nil {
 		out.WriteString(ae.Value.String())
@@ -465,12 +539,308 @@ func (ae *AssignmentExpression) String() string {
 	return out.String()
 }
 
+// ----------------------------------------------------
+// Index Assignment Expression Struct
+// ----------------------------------------------------
+type IndexAssignmentExpression struct {
+	Token token.Token // The `=` token
+	Left  Expression  // The indexed collection
+	Index Expression
+	Value Expression
+}
+
+func (ia *IndexAssignmentExpression) expressionNode() {}
+
+func (ia *IndexAssignmentExpression) TokenLiteral() string {
+	return ia.Token.Literal
+}
+
+func (ia *IndexAssignmentExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ia.Left.String())
+	out.WriteString("[")
+	out.WriteString(ia.Index.String())
+	out.WriteString("] = ")
+
+	if ia.Value != nil {
+		out.WriteString(ia.Value.String())
+	}
+
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// ----------------------------------------------------
+// Destructuring Assignment Struct
+// ----------------------------------------------------
+type DestructuringAssignment struct {
+	Token token.Token // The `=` token
+	Names []*Identifier
+	Value Expression
+}
+
+func (da *DestructuringAssignment) expressionNode() {}
+
+func (da *DestructuringAssignment) TokenLiteral() string {
+	return da.Token.Literal
+}
+
+func (da *DestructuringAssignment) String() string {
+	var out bytes.Buffer
+
+	names := []string{}
+	for _, name := range da.Names {
+		names = append(names, name.String())
+	}
+
+	out.WriteString("([")
+	out.WriteString(strings.Join(names, ", "))
+	out.WriteString("] = ")
+
+	if da.Value != nil {
+		out.WriteString(da.Value.String())
+	}
+
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// ----------------------------------------------------
+// Sequence Expression Struct
+// ----------------------------------------------------
+type SequenceExpression struct {
+	Token       token.Token // The `(` token
+	Expressions []Expression
+}
+
+func (se *SequenceExpression) expressionNode() {}
+
+func (se *SequenceExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+func (se *SequenceExpression) String() string {
+	var out bytes.Buffer
+
+	exps := []string{}
+	for _, exp := range se.Expressions {
+		exps = append(exps, exp.String())
+	}
+
+	out.WriteString("(")
+	out.WriteString(strings.Join(exps, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// ----------------------------------------------------
+// While Expression Struct
+// ----------------------------------------------------
+type WhileExpression struct {
+	Token     token.Token // The `while` token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (we *WhileExpression) expressionNode() {}
+
+func (we *WhileExpression) TokenLiteral() string {
+	return we.Token.Literal
+}
+
+func (we *WhileExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("while")
+	out.WriteString(we.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(we.Body.String())
+
+	return out.String()
+}
+
+// ----------------------------------------------------
+// For Expression Struct
+// ----------------------------------------------------
+type ForExpression struct {
+	Token     token.Token // The `for` token
+	Init      Statement   // Optional
+	Condition Expression  // Optional, loop forever when missing
+	Post      Expression  // Optional
+	Body      *BlockStatement
+}
+
+func (fe *ForExpression) expressionNode() {}
+
+func (fe *ForExpression) TokenLiteral() string {
+	return fe.Token.Literal
+}
+
+func (fe *ForExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+
+	if fe.Init != nil {
+		out.WriteString(strings.TrimSuffix(fe.Init.String(), ";"))
+	}
+
+	out.WriteString("; ")
+
+	if fe.Condition != nil {
+		out.WriteString(fe.Condition.String())
+	}
+
+	out.WriteString("; ")
+
+	if fe.Post != nil {
+		out.WriteString(fe.Post.String())
+	}
+
+	out.WriteString(") ")
+	out.WriteString(fe.Body.String())
+
+	return out.String()
+}
+
+// ----------------------------------------------------
+// ForIn Statement Struct
+// ----------------------------------------------------
+type ForInStatement struct {
+	Token    token.Token // The `for` token
+	Key      *Identifier // Optional. Index for arrays, key for hashes
+	Value    *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForInStatement) statementNode() {}
+
+func (fs *ForInStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+func (fs *ForInStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+
+	if fs.Key != nil {
+		out.WriteString(fs.Key.String() + ", ")
+	}
+
+	out.WriteString(fs.Value.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
+// ----------------------------------------------------
+// Break Statement Struct
+// ----------------------------------------------------
+type BreakStatement struct {
+	Token token.Token // The `break` token
+}
+
+func (bs *BreakStatement) statementNode() {}
+
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+func (bs *BreakStatement) String() string {
+	return bs.Token.Literal + ";"
+}
+
+// ----------------------------------------------------
+// Continue Statement Struct
+// ----------------------------------------------------
+type ContinueStatement struct {
+	Token token.Token // The `continue` token
+}
+
+func (cs *ContinueStatement) statementNode() {}
+
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *ContinueStatement) String() string {
+	return cs.Token.Literal + ";"
+}
+
+// ----------------------------------------------------
+// Pipe Expression Struct
+// ----------------------------------------------------
+type PipeExpression struct {
+	Token token.Token // The `|>` token
+	Left  Expression  // The piped value
+	Right Expression  // The function, or a call where `_` mark the piped value position
+}
+
+func (pe *PipeExpression) expressionNode() {}
+
+func (pe *PipeExpression) TokenLiteral() string {
+	return pe.Token.Literal
+}
+
+func (pe *PipeExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(" |> ")
+	out.WriteString(pe.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// ----------------------------------------------------
+// Ternary Expression Struct
+// ----------------------------------------------------
+type TernaryExpression struct {
+	Token       token.Token // The `?` token
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (te *TernaryExpression) expressionNode() {}
+
+func (te *TernaryExpression) TokenLiteral() string {
+	return te.Token.Literal
+}
+
+func (te *TernaryExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(te.Condition.String())
+	out.WriteString(" ? ")
+	out.WriteString(te.Consequence.String())
+	out.WriteString(" : ")
+	out.WriteString(te.Alternative.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
 // ----------------------------------------------------
 // HashMap Struct
 // ----------------------------------------------------
 type HashLiteral struct {
 	Token token.Token // The `{` token
 	Pairs map[Expression]Expression
+	Keys  []Expression // The keys of `Pairs` in source order
 }
 
 func (hl *HashLiteral) expressionNode() {}
@@ -2,9 +2,58 @@ package evaluator
 
 import (
 	"Monkey/object"
+	"bufio"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
+// MAX_COMBINATORIC_RESULTS cap the output size of `combinations` and `permutations`
+const MAX_COMBINATORIC_RESULTS = 100000
+
+// MAX_RANGE_LENGTH cap how many elements `range` build eagerly, `xrange` has no such limit
+const MAX_RANGE_LENGTH = 10000000
+
+// VERSION is the interpreter version reported by `version()`
+const VERSION = "0.1.0"
+
+// languageFeatures list the syntax supported beyond the original Monkey, so scripts can check
+// for one with `in` before relying on it. New syntax must be added here, TestLanguageFeatures
+// run a snippet for every entry
+var languageFeatures = []string{
+	"arrow_functions", "break_continue", "comments", "comparisons", "conditional_assignment",
+	"destructuring", "digit_separators", "floats", "for", "for_in", "in", "index_assignment",
+	"modulo", "null", "pipe", "power", "ranges", "shifts", "slices", "string_escapes", "ternary",
+	"while",
+}
+
+// ScriptArgs hold the command-line arguments passed after the script path
+var ScriptArgs = []string{}
+
+// sleep is swappable so tests dont have to actually wait
+var sleep = time.Sleep
+
+// exit is swappable so tests can check the requested code without stopping the test binary
+var exit = os.Exit
+
+// stdin is shared by every builtin reading input so buffered data is never lost between them
+var stdin = bufio.NewReader(os.Stdin)
+
+// builtins is the default table shared by every interpreter and is never modified after init,
+// custom builtins are registered per interpreter with `Environment.RegisterBuiltin`
 var builtins = map[string]*object.Builtin{
 	"len": {
 		Fn: func(args ...object.Object) object.Object {
@@ -19,6 +68,9 @@ var builtins = map[string]*object.Builtin{
 			case *object.String:
 				return &object.Integer{Value: int64(len(arg.Value))}
 
+			case *object.Range:
+				return &object.Integer{Value: arg.Len()}
+
 			default:
 				return newError("argument to `len` not supported, got=%s", args[0].Type())
 			}
@@ -99,6 +151,11 @@ var builtins = map[string]*object.Builtin{
 
 			arr := args[0].(*object.Array)
 			length := len(arr.Elements)
+
+			if errObj := checkArrayLength(length + 1); errObj != nil {
+				return errObj
+			}
+
 			newArr := make([]object.Object, length+1)
 
 			copy(newArr, arr.Elements)
@@ -115,4 +172,2523 @@ var builtins = map[string]*object.Builtin{
 			return NULL
 		},
 	},
+	"args": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 0)
+			}
+
+			elements := make([]object.Object, len(ScriptArgs))
+
+			for i, arg := range ScriptArgs {
+				elements[i] = &object.String{Value: arg}
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"env": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+
+			name, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("first argument to `env` must be a STRING, got=%s", args[0].Type())
+			}
+
+			if val, ok := os.LookupEnv(name.Value); ok {
+				return &object.String{Value: val}
+			}
+
+			// Fallback to the default value if one is given
+			if len(args) == 2 {
+				return args[1]
+			}
+
+			return NULL
+		},
+	},
+	"sleep": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			ms, ok := args[0].(*object.Integer)
+
+			if !ok {
+				return newError("argument to `sleep` must be an INTEGER, got=%s", args[0].Type())
+			}
+
+			if ms.Value < 0 {
+				return newError("argument to `sleep` must not be negative, got=%d", ms.Value)
+			}
+
+			sleep(time.Duration(ms.Value) * time.Millisecond)
+			return NULL
+		},
+	},
+	"digits": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+
+			n, ok := args[0].(*object.Integer)
+
+			if !ok {
+				return newError("first argument to `digits` must be an INTEGER, got=%s", args[0].Type())
+			}
+
+			if n.Value < 0 {
+				return newError("first argument to `digits` must not be negative, got=%d", n.Value)
+			}
+
+			base := int64(10)
+
+			if len(args) == 2 {
+				b, ok := args[1].(*object.Integer)
+
+				if !ok {
+					return newError("second argument to `digits` must be an INTEGER, got=%s", args[1].Type())
+				}
+
+				if b.Value < 2 {
+					return newError("base for `digits` must be at least 2, got=%d", b.Value)
+				}
+
+				base = b.Value
+			}
+
+			// Collect from the least significant digit, then reverse
+			value := n.Value
+			digits := []object.Object{&object.Integer{Value: value % base}}
+
+			for value /= base; value > 0; value /= base {
+				digits = append(digits, &object.Integer{Value: value % base})
+			}
+
+			for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+				digits[i], digits[j] = digits[j], digits[i]
+			}
+
+			return &object.Array{Elements: digits}
+		},
+	},
+	"flatten_hash": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			hash, ok := args[0].(*object.Hash)
+
+			if !ok {
+				return newError("argument to `flatten_hash` must be a HASH, got=%s", args[0].Type())
+			}
+
+			flat := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+
+			for _, pair := range hash.OrderedPairs() {
+				flattenInto(flat, pair.Key.Inspect(), pair.Value)
+			}
+
+			return flat
+		},
+	},
+	"deep_merge": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+			}
+
+			a, ok := args[0].(*object.Hash)
+
+			if !ok {
+				return newError("first argument to `deep_merge` must be a HASH, got=%s", args[0].Type())
+			}
+
+			b, ok := args[1].(*object.Hash)
+
+			if !ok {
+				return newError("second argument to `deep_merge` must be a HASH, got=%s", args[1].Type())
+			}
+
+			merged, errObj := deepMerge(a, b)
+
+			if errObj != nil {
+				return errObj
+			}
+
+			return merged
+		},
+	},
+	// max_depth() => 3. Like `locals`, the evaluator intercept the call to read the running
+	// interpreter state, reaching here mean it was invoked indirectly
+	"max_depth": {
+		Fn: func(args ...object.Object) object.Object {
+			return newError("`max_depth` must be called directly")
+		},
+	},
+	"template": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("first argument to `template` must be a STRING, got=%s", args[0].Type())
+			}
+
+			hash, ok := args[1].(*object.Hash)
+
+			if !ok {
+				return newError("second argument to `template` must be a HASH, got=%s", args[1].Type())
+			}
+
+			// Replace every `{name}` with the value of "name", unknown placeholders are left intact
+			var out strings.Builder
+			rest := str.Value
+
+			for {
+				start := strings.IndexByte(rest, '{')
+
+				if start < 0 {
+					break
+				}
+
+				end := strings.IndexByte(rest[start:], '}')
+
+				if end < 0 {
+					break
+				}
+
+				end += start
+				name := &object.String{Value: rest[start+1 : end]}
+
+				out.WriteString(rest[:start])
+
+				if pair, ok := hash.Pairs[name.HashKey()]; ok {
+					out.WriteString(pair.Value.Inspect())
+				} else {
+					out.WriteString(rest[start : end+1])
+				}
+
+				rest = rest[end+1:]
+			}
+
+			out.WriteString(rest)
+
+			return &object.String{Value: out.String()}
+		},
+	},
+	"stats": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			arr, ok := args[0].(*object.Array)
+
+			if !ok {
+				return newError("argument to `stats` must be an ARRAY, got=%s", args[0].Type())
+			}
+
+			if len(arr.Elements) == 0 {
+				return newError("argument to `stats` must not be empty")
+			}
+
+			// min and max keep the type of the element they come from, the sum only
+			// become a FLOAT once a FLOAT element is seen
+			var min, max object.Object
+			var intSum int64
+			var floatSum float64
+			hasFloat := false
+
+			for i, elem := range arr.Elements {
+				if !isNumber(elem) {
+					return newError("element of `stats` must be an INTEGER or FLOAT, got=%s", elem.Type())
+				}
+
+				if i == 0 || toFloat(elem) < toFloat(min) {
+					min = elem
+				}
+
+				if i == 0 || toFloat(elem) > toFloat(max) {
+					max = elem
+				}
+
+				switch elem := elem.(type) {
+				case *object.Integer:
+					intSum += elem.Value
+				case *object.Float:
+					floatSum += elem.Value
+					hasFloat = true
+				}
+			}
+
+			count := int64(len(arr.Elements))
+			sum := object.Object(&object.Integer{Value: intSum})
+
+			if hasFloat {
+				sum = &object.Float{Value: float64(intSum) + floatSum}
+			}
+
+			return newHash(map[string]object.Object{
+				"min":   min,
+				"max":   max,
+				"sum":   sum,
+				"mean":  &object.Float{Value: toFloat(sum) / float64(count)},
+				"count": &object.Integer{Value: count},
+			})
+		},
+	},
+	"get_path": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 3)
+			}
+
+			path, ok := args[1].(*object.Array)
+
+			if !ok {
+				return newError("second argument to `get_path` must be an ARRAY, got=%s", args[1].Type())
+			}
+
+			current := args[0]
+
+			// Any missing step fallback to the default value
+			for _, step := range path.Elements {
+				switch data := current.(type) {
+				case *object.Hash:
+					key, ok := step.(object.Hashable)
+
+					if !ok {
+						return args[2]
+					}
+
+					pair, ok := data.Pairs[key.HashKey()]
+
+					if !ok {
+						return args[2]
+					}
+
+					current = pair.Value
+
+				case *object.Array:
+					idx, ok := step.(*object.Integer)
+
+					if !ok || idx.Value < 0 || idx.Value >= int64(len(data.Elements)) {
+						return args[2]
+					}
+
+					current = data.Elements[idx.Value]
+
+				default:
+					return args[2]
+				}
+			}
+
+			return current
+		},
+	},
+	"combinations": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+			}
+
+			arr, ok := args[0].(*object.Array)
+
+			if !ok {
+				return newError("first argument to `combinations` must be an ARRAY, got=%s", args[0].Type())
+			}
+
+			k, ok := args[1].(*object.Integer)
+
+			if !ok {
+				return newError("second argument to `combinations` must be an INTEGER, got=%s", args[1].Type())
+			}
+
+			n := int64(len(arr.Elements))
+
+			if k.Value < 0 || k.Value > n {
+				return &object.Array{Elements: []object.Object{}}
+			}
+
+			// C(n, k) computed incrementally so we can bail out before it grow too big
+			count := int64(1)
+
+			for i := int64(1); i <= k.Value; i++ {
+				count = count * (n - k.Value + i) / i
+
+				if count > MAX_COMBINATORIC_RESULTS {
+					return newError("too many combinations, limit is %d", MAX_COMBINATORIC_RESULTS)
+				}
+			}
+
+			results := []object.Object{}
+			picked := []object.Object{}
+
+			var combine func(start int)
+			combine = func(start int) {
+				if int64(len(picked)) == k.Value {
+					elements := make([]object.Object, len(picked))
+					copy(elements, picked)
+					results = append(results, &object.Array{Elements: elements})
+					return
+				}
+
+				for i := start; i < len(arr.Elements); i++ {
+					picked = append(picked, arr.Elements[i])
+					combine(i + 1)
+					picked = picked[:len(picked)-1]
+				}
+			}
+
+			combine(0)
+
+			return &object.Array{Elements: results}
+		},
+	},
+	"permutations": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			arr, ok := args[0].(*object.Array)
+
+			if !ok {
+				return newError("argument to `permutations` must be an ARRAY, got=%s", args[0].Type())
+			}
+
+			count := int64(1)
+
+			for i := int64(2); i <= int64(len(arr.Elements)); i++ {
+				count *= i
+
+				if count > MAX_COMBINATORIC_RESULTS {
+					return newError("too many permutations, limit is %d", MAX_COMBINATORIC_RESULTS)
+				}
+			}
+
+			results := []object.Object{}
+			elements := make([]object.Object, len(arr.Elements))
+			copy(elements, arr.Elements)
+
+			// Each position take every remaining element in turn by swapping it into place
+			var permute func(start int)
+			permute = func(start int) {
+				if start == len(elements) {
+					perm := make([]object.Object, len(elements))
+					copy(perm, elements)
+					results = append(results, &object.Array{Elements: perm})
+					return
+				}
+
+				for i := start; i < len(elements); i++ {
+					elements[start], elements[i] = elements[i], elements[start]
+					permute(start + 1)
+					elements[start], elements[i] = elements[i], elements[start]
+				}
+			}
+
+			permute(0)
+
+			return &object.Array{Elements: results}
+		},
+	},
+	"byte_len": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `byte_len` must be a STRING, got=%s", args[0].Type())
+			}
+
+			return &object.Integer{Value: int64(len(str.Value))}
+		},
+	},
+	"rune_len": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `rune_len` must be a STRING, got=%s", args[0].Type())
+			}
+
+			return &object.Integer{Value: int64(utf8.RuneCountInString(str.Value))}
+		},
+	},
+	"url_encode": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `url_encode` must be a STRING, got=%s", args[0].Type())
+			}
+
+			return &object.String{Value: url.QueryEscape(str.Value)}
+		},
+	},
+	"url_decode": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `url_decode` must be a STRING, got=%s", args[0].Type())
+			}
+
+			decoded, err := url.QueryUnescape(str.Value)
+
+			if err != nil {
+				return newError("could not decode %q: %s", str.Value, err)
+			}
+
+			return &object.String{Value: decoded}
+		},
+	},
+	"base64_encode": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `base64_encode` must be a STRING, got=%s", args[0].Type())
+			}
+
+			return &object.String{Value: base64.StdEncoding.EncodeToString([]byte(str.Value))}
+		},
+	},
+	"base64_decode": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `base64_decode` must be a STRING, got=%s", args[0].Type())
+			}
+
+			decoded, err := base64.StdEncoding.DecodeString(str.Value)
+
+			if err != nil {
+				return newError("could not decode %q: %s", str.Value, err)
+			}
+
+			return &object.String{Value: string(decoded)}
+		},
+	},
+	"sha256": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `sha256` must be a STRING, got=%s", args[0].Type())
+			}
+
+			sum := sha256.Sum256([]byte(str.Value))
+			return &object.String{Value: hex.EncodeToString(sum[:])}
+		},
+	},
+	"md5": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `md5` must be a STRING, got=%s", args[0].Type())
+			}
+
+			sum := md5.Sum([]byte(str.Value))
+			return &object.String{Value: hex.EncodeToString(sum[:])}
+		},
+	},
+	"read_all": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 0)
+			}
+
+			data, err := io.ReadAll(stdin)
+
+			if err != nil {
+				return newError("could not read stdin: %s", err)
+			}
+
+			return &object.String{Value: string(data)}
+		},
+	},
+	// read_line() => "next line". Return NULL once stdin is exhausted
+	"read_line": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 0)
+			}
+
+			line, err := stdin.ReadString('\n')
+
+			if err != nil && err != io.EOF {
+				return newError("could not read stdin: %s", err)
+			}
+
+			// The last line might not end with a newline, only a bare EOF mean no more input
+			if err == io.EOF && line == "" {
+				return NULL
+			}
+
+			line = strings.TrimSuffix(line, "\n")
+			line = strings.TrimSuffix(line, "\r")
+
+			return &object.String{Value: line}
+		},
+	},
+	"matches": {
+		Fn: func(args ...object.Object) object.Object {
+			str, re, errObj := regexArgs("matches", args)
+
+			if errObj != nil {
+				return errObj
+			}
+
+			return nativeBoolToBooleanObject(re.MatchString(str))
+		},
+	},
+	"find_all": {
+		Fn: func(args ...object.Object) object.Object {
+			str, re, errObj := regexArgs("find_all", args)
+
+			if errObj != nil {
+				return errObj
+			}
+
+			results := []object.Object{}
+
+			// Without groups each match is a string, with groups each match is an array of the captures
+			for _, match := range re.FindAllStringSubmatch(str, -1) {
+				if len(match) == 1 {
+					results = append(results, &object.String{Value: match[0]})
+					continue
+				}
+
+				groups := []object.Object{}
+
+				for _, group := range match[1:] {
+					groups = append(groups, &object.String{Value: group})
+				}
+
+				results = append(results, &object.Array{Elements: groups})
+			}
+
+			return &object.Array{Elements: results}
+		},
+	},
+	"regex_replace": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 3)
+			}
+
+			str, re, errObj := regexArgs("regex_replace", args[:2])
+
+			if errObj != nil {
+				return errObj
+			}
+
+			replacement, ok := args[2].(*object.String)
+
+			if !ok {
+				return newError("third argument to `regex_replace` must be a STRING, got=%s", args[2].Type())
+			}
+
+			return &object.String{Value: re.ReplaceAllString(str, replacement.Value)}
+		},
+	},
+	"split": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("first argument to `split` must be a STRING, got=%s", args[0].Type())
+			}
+
+			sep, ok := args[1].(*object.String)
+
+			if !ok {
+				return newError("second argument to `split` must be a STRING, got=%s", args[1].Type())
+			}
+
+			// An empty separator split the string into its characters
+			parts := strings.Split(str.Value, sep.Value)
+			elements := make([]object.Object, len(parts))
+
+			for i, part := range parts {
+				elements[i] = &object.String{Value: part}
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"format_number": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 2 {
+				return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+
+			sep := ","
+
+			if len(args) == 2 {
+				str, ok := args[1].(*object.String)
+
+				if !ok {
+					return newError("second argument to `format_number` must be a STRING, got=%s", args[1].Type())
+				}
+
+				sep = str.Value
+			}
+
+			var digits string
+
+			switch n := args[0].(type) {
+			case *object.Integer:
+				digits = strconv.FormatInt(n.Value, 10)
+			case *object.Float:
+				digits = strconv.FormatFloat(n.Value, 'f', -1, 64)
+			default:
+				return newError("first argument to `format_number` must be an INTEGER or FLOAT, got=%s", args[0].Type())
+			}
+
+			sign := ""
+
+			if strings.HasPrefix(digits, "-") {
+				sign, digits = "-", digits[1:]
+			}
+
+			// Only the whole part is grouped, the decimals are kept as is
+			whole, fraction := digits, ""
+
+			if dot := strings.IndexByte(digits, '.'); dot >= 0 {
+				whole, fraction = digits[:dot], digits[dot:]
+			}
+
+			var out strings.Builder
+
+			for i, ch := range whole {
+				if i > 0 && (len(whole)-i)%3 == 0 {
+					out.WriteString(sep)
+				}
+
+				out.WriteRune(ch)
+			}
+
+			return &object.String{Value: sign + out.String() + fraction}
+		},
+	},
+	"join": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+			}
+
+			arr, ok := args[0].(*object.Array)
+
+			if !ok {
+				return newError("first argument to `join` must be an ARRAY, got=%s", args[0].Type())
+			}
+
+			sep, ok := args[1].(*object.String)
+
+			if !ok {
+				return newError("second argument to `join` must be a STRING, got=%s", args[1].Type())
+			}
+
+			parts := make([]string, len(arr.Elements))
+
+			for i, elem := range arr.Elements {
+				str, ok := elem.(*object.String)
+
+				if !ok {
+					return newError("elements of `join` must be STRING, got=%s at index %d", elem.Type(), i)
+				}
+
+				parts[i] = str.Value
+			}
+
+			return &object.String{Value: strings.Join(parts, sep.Value)}
+		},
+	},
+	"type": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			return &object.String{Value: string(args[0].Type())}
+		},
+	},
+	"invert": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			hash, ok := args[0].(*object.Hash)
+
+			if !ok {
+				return newError("argument to `invert` must be a HASH, got=%s", args[0].Type())
+			}
+
+			inverted := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+
+			// When several keys share a value, the last inserted key wins
+			for _, pair := range hash.OrderedPairs() {
+				value, ok := pair.Value.(object.Hashable)
+
+				if !ok {
+					return newError("unusable as hash key: %s", pair.Value.Type())
+				}
+
+				inverted.Set(value.HashKey(), object.HashPair{Key: pair.Value, Value: pair.Key})
+			}
+
+			return inverted
+		},
+	},
+	"int": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return arg
+
+			case *object.Float:
+				return &object.Integer{Value: int64(arg.Value)}
+
+			case *object.String:
+				value, err := strconv.ParseInt(arg.Value, 10, 64)
+
+				if err != nil {
+					return newError("could not convert %q to INTEGER", arg.Value)
+				}
+
+				return &object.Integer{Value: value}
+
+			default:
+				return newError("argument to `int` must be a STRING, INTEGER or FLOAT, got=%s", args[0].Type())
+			}
+		},
+	},
+	"str": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			if str, ok := args[0].(*object.String); ok {
+				return str
+			}
+
+			return &object.String{Value: args[0].Inspect()}
+		},
+	},
+	"frequencies": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			arr, ok := args[0].(*object.Array)
+
+			if !ok {
+				return newError("argument to `frequencies` must be an ARRAY, got=%s", args[0].Type())
+			}
+
+			counts := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+
+			for _, elem := range arr.Elements {
+				key, ok := elem.(object.Hashable)
+
+				if !ok {
+					return newError("unusable as hash key: %s", elem.Type())
+				}
+
+				count := int64(1)
+
+				if pair, ok := counts.Pairs[key.HashKey()]; ok {
+					count = pair.Value.(*object.Integer).Value + 1
+				}
+
+				counts.Set(key.HashKey(), object.HashPair{Key: elem, Value: &object.Integer{Value: count}})
+			}
+
+			return counts
+		},
+	},
+	"delete": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+			}
+
+			hash, ok := args[0].(*object.Hash)
+
+			if !ok {
+				return newError("first argument to `delete` must be a HASH, got=%s", args[0].Type())
+			}
+
+			key, ok := args[1].(object.Hashable)
+
+			if !ok {
+				return newError("unusable as hash key: %s", args[1].Type())
+			}
+
+			// Copy in order so the remaining keys keep their insertion order
+			result := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair, len(hash.Pairs))}
+
+			for _, pair := range hash.OrderedPairs() {
+				hashKey := pair.Key.(object.Hashable).HashKey()
+
+				if hashKey != key.HashKey() {
+					result.Set(hashKey, pair)
+				}
+			}
+
+			return result
+		},
+	},
+	"chunk": {
+		Fn: func(args ...object.Object) object.Object {
+			arr, n, errObj := arraySizeArgs("chunk", args)
+
+			if errObj != nil {
+				return errObj
+			}
+
+			chunks := []object.Object{}
+
+			for start := 0; start < len(arr.Elements); start += n {
+				end := start + n
+
+				if end > len(arr.Elements) {
+					end = len(arr.Elements)
+				}
+
+				elements := make([]object.Object, end-start)
+				copy(elements, arr.Elements[start:end])
+				chunks = append(chunks, &object.Array{Elements: elements})
+			}
+
+			return &object.Array{Elements: chunks}
+		},
+	},
+	"windows": {
+		Fn: func(args ...object.Object) object.Object {
+			arr, n, errObj := arraySizeArgs("windows", args)
+
+			if errObj != nil {
+				return errObj
+			}
+
+			windows := []object.Object{}
+
+			for start := 0; start+n <= len(arr.Elements); start++ {
+				elements := make([]object.Object, n)
+				copy(elements, arr.Elements[start:start+n])
+				windows = append(windows, &object.Array{Elements: elements})
+			}
+
+			return &object.Array{Elements: windows}
+		},
+	},
+	"keys": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			hash, ok := args[0].(*object.Hash)
+
+			if !ok {
+				return newError("argument to `keys` must be a HASH, got=%s", args[0].Type())
+			}
+
+			elements := []object.Object{}
+
+			for _, pair := range hash.OrderedPairs() {
+				elements = append(elements, pair.Key)
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"values": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			hash, ok := args[0].(*object.Hash)
+
+			if !ok {
+				return newError("argument to `values` must be a HASH, got=%s", args[0].Type())
+			}
+
+			elements := []object.Object{}
+
+			for _, pair := range hash.OrderedPairs() {
+				elements = append(elements, pair.Value)
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"sort_entries_by_value": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			hash, ok := args[0].(*object.Hash)
+
+			if !ok {
+				return newError("argument to `sort_entries_by_value` must be a HASH, got=%s", args[0].Type())
+			}
+
+			// Start from the insertion order so entries with equal values keep a stable order
+			pairs := hash.OrderedPairs()
+
+			// Only integers or strings can be ordered, and never both in the same hash
+			for _, pair := range pairs {
+				valueType := pair.Value.Type()
+
+				if valueType != object.INTEGER_OBJ && valueType != object.STRING_OBJ {
+					return newError("values of `sort_entries_by_value` must be INTEGER or STRING, got=%s", valueType)
+				}
+
+				if valueType != pairs[0].Value.Type() {
+					return newError("values of `sort_entries_by_value` are not comparable: %s and %s", pairs[0].Value.Type(), valueType)
+				}
+			}
+
+			sort.SliceStable(pairs, func(i, j int) bool {
+				switch left := pairs[i].Value.(type) {
+				case *object.Integer:
+					return left.Value < pairs[j].Value.(*object.Integer).Value
+				default:
+					return left.(*object.String).Value < pairs[j].Value.(*object.String).Value
+				}
+			})
+
+			entries := make([]object.Object, len(pairs))
+
+			for i, pair := range pairs {
+				entries[i] = &object.Array{Elements: []object.Object{pair.Key, pair.Value}}
+			}
+
+			return &object.Array{Elements: entries}
+		},
+	},
+	"format": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 {
+				return newError("wrong number of arguments. got=%d, want>=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("first argument to `format` must be a STRING, got=%s", args[0].Type())
+			}
+
+			// Fill every `{}` or `{:spec}` with the next argument in order, `{{` and `}}` are literal braces
+			var out strings.Builder
+			rest := str.Value
+			values := args[1:]
+			used := 0
+			unescape := strings.NewReplacer("}}", "}")
+
+			for {
+				start := strings.IndexByte(rest, '{')
+
+				if start < 0 {
+					break
+				}
+
+				if strings.HasPrefix(rest[start:], "{{") {
+					out.WriteString(unescape.Replace(rest[:start]))
+					out.WriteString("{")
+					rest = rest[start+2:]
+					continue
+				}
+
+				end := strings.IndexByte(rest[start:], '}')
+
+				if end < 0 {
+					return newError("unclosed placeholder in `format`: %q", rest[start:])
+				}
+
+				end += start
+				placeholder := rest[start+1 : end]
+
+				if placeholder != "" && !strings.HasPrefix(placeholder, ":") {
+					return newError("invalid placeholder in `format`: {%s}", placeholder)
+				}
+
+				if used == len(values) {
+					return newError("not enough arguments to `format`. got=%d", len(values))
+				}
+
+				field, errObj := formatField(values[used], strings.TrimPrefix(placeholder, ":"))
+
+				if errObj != nil {
+					return errObj
+				}
+
+				out.WriteString(unescape.Replace(rest[:start]))
+				out.WriteString(field)
+
+				used++
+				rest = rest[end+1:]
+			}
+
+			if used != len(values) {
+				return newError("too many arguments to `format`. got=%d, want=%d", len(values), used)
+			}
+
+			out.WriteString(unescape.Replace(rest))
+
+			return &object.String{Value: out.String()}
+		},
+	},
+	"parse_csv": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `parse_csv` must be a STRING, got=%s", args[0].Type())
+			}
+
+			reader := csv.NewReader(strings.NewReader(str.Value))
+			reader.FieldsPerRecord = -1 // rows may have different number of fields
+
+			records, err := reader.ReadAll()
+
+			if err != nil {
+				return newError("could not parse csv: %s", err)
+			}
+
+			rows := make([]object.Object, len(records))
+
+			for i, record := range records {
+				if errObj := checkArrayLength(len(record)); errObj != nil {
+					return errObj
+				}
+
+				fields := make([]object.Object, len(record))
+
+				for j, field := range record {
+					fields[j] = &object.String{Value: field}
+				}
+
+				rows[i] = &object.Array{Elements: fields}
+			}
+
+			return &object.Array{Elements: rows}
+		},
+	},
+	"to_csv": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			rows, ok := args[0].(*object.Array)
+
+			if !ok {
+				return newError("argument to `to_csv` must be an ARRAY, got=%s", args[0].Type())
+			}
+
+			var out strings.Builder
+			writer := csv.NewWriter(&out)
+
+			for i, elem := range rows.Elements {
+				row, ok := elem.(*object.Array)
+
+				if !ok {
+					return newError("rows of `to_csv` must be ARRAY, got=%s at index %d", elem.Type(), i)
+				}
+
+				// Strings are written as is, anything else in its printed form
+				record := make([]string, len(row.Elements))
+
+				for j, field := range row.Elements {
+					if str, ok := field.(*object.String); ok {
+						record[j] = str.Value
+					} else {
+						record[j] = field.Inspect()
+					}
+				}
+
+				if err := writer.Write(record); err != nil {
+					return newError("could not write csv: %s", err)
+				}
+			}
+
+			writer.Flush()
+
+			return &object.String{Value: out.String()}
+		},
+	},
+	"snake_case": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `snake_case` must be a STRING, got=%s", args[0].Type())
+			}
+
+			words := splitWords(str.Value)
+
+			for i, word := range words {
+				words[i] = strings.ToLower(word)
+			}
+
+			return &object.String{Value: strings.Join(words, "_")}
+		},
+	},
+	"camel_case": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `camel_case` must be a STRING, got=%s", args[0].Type())
+			}
+
+			var out strings.Builder
+
+			for i, word := range splitWords(str.Value) {
+				word = strings.ToLower(word)
+
+				if i > 0 {
+					first, size := utf8.DecodeRuneInString(word)
+					word = string(unicode.ToUpper(first)) + word[size:]
+				}
+
+				out.WriteString(word)
+			}
+
+			return &object.String{Value: out.String()}
+		},
+	},
+	"xrange": {
+		Fn: func(args ...object.Object) object.Object {
+			r, errObj := rangeArgs("xrange", args)
+
+			if errObj != nil {
+				return errObj
+			}
+
+			return r
+		},
+	},
+	"range": {
+		Fn: func(args ...object.Object) object.Object {
+			r, errObj := rangeArgs("range", args)
+
+			if errObj != nil {
+				return errObj
+			}
+
+			length := r.Len()
+
+			if length > MAX_RANGE_LENGTH {
+				return newError("`range` would build %d elements, more than the limit of %d. use `xrange` instead",
+					length, MAX_RANGE_LENGTH)
+			}
+
+			if errObj := checkArrayLength(int(length)); errObj != nil {
+				return errObj
+			}
+
+			elements := make([]object.Object, length)
+
+			for i := range elements {
+				elements[i] = &object.Integer{Value: r.At(int64(i))}
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"pretty": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			var out strings.Builder
+			writePretty(&out, args[0], 0, map[object.Object]bool{})
+
+			return &object.String{Value: out.String()}
+		},
+	},
+	"deep_size": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			return &object.Integer{Value: deepSize(args[0], map[object.Object]bool{})}
+		},
+	},
+	"ordered_keys": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			hash, ok := args[0].(*object.Hash)
+
+			if !ok {
+				return newError("argument to `ordered_keys` must be a HASH, got=%s", args[0].Type())
+			}
+
+			keys := []object.Object{}
+
+			for _, pair := range hash.OrderedPairs() {
+				keys = append(keys, pair.Key)
+			}
+
+			return &object.Array{Elements: keys}
+		},
+	},
+	"reorder": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+			}
+
+			hash, ok := args[0].(*object.Hash)
+
+			if !ok {
+				return newError("first argument to `reorder` must be a HASH, got=%s", args[0].Type())
+			}
+
+			keys, ok := args[1].(*object.Array)
+
+			if !ok {
+				return newError("second argument to `reorder` must be an ARRAY, got=%s", args[1].Type())
+			}
+
+			// The keys must name every pair of the hash exactly once
+			result := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair, len(hash.Pairs))}
+
+			for _, key := range keys.Elements {
+				hashable, ok := key.(object.Hashable)
+
+				if !ok {
+					return newError("unusable as hash key: %s", key.Type())
+				}
+
+				pair, ok := hash.Pairs[hashable.HashKey()]
+
+				if !ok {
+					return newError("key %s not found in hash", key.Inspect())
+				}
+
+				if _, ok := result.Pairs[hashable.HashKey()]; ok {
+					return newError("duplicate key %s in `reorder`", key.Inspect())
+				}
+
+				result.Set(hashable.HashKey(), pair)
+			}
+
+			if len(result.Pairs) != len(hash.Pairs) {
+				return newError("`reorder` is missing %d key(s) of the hash", len(hash.Pairs)-len(result.Pairs))
+			}
+
+			return result
+		},
+	},
+	"interleave": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 {
+				return newError("wrong number of arguments. got=%d, want>=%d", len(args), 1)
+			}
+
+			arrays := make([]*object.Array, len(args))
+			shortest := -1
+
+			for i, arg := range args {
+				arr, ok := arg.(*object.Array)
+
+				if !ok {
+					return newError("arguments to `interleave` must be ARRAY, got=%s", arg.Type())
+				}
+
+				if shortest < 0 || len(arr.Elements) < shortest {
+					shortest = len(arr.Elements)
+				}
+
+				arrays[i] = arr
+			}
+
+			// interleave([1, 2], ["a", "b"]) => [1, "a", 2, "b"]
+			elements := make([]object.Object, 0, shortest*len(arrays))
+
+			for i := 0; i < shortest; i++ {
+				for _, arr := range arrays {
+					elements = append(elements, arr.Elements[i])
+				}
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	// locals() => {"x": 1, "y": 2}. The evaluator intercept the call and supply the caller
+	// environment, reaching here mean it was invoked indirectly, eg: map(xs, locals)
+	"locals": {
+		Fn: func(args ...object.Object) object.Object {
+			return newError("`locals` must be called directly")
+		},
+	},
+	// pop([1, 2, 3]) => [3, [1, 2]]. Return the last element together with a new array holding
+	// the remaining elements, the original array is left untouched
+	"pop": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			arr, ok := args[0].(*object.Array)
+
+			if !ok {
+				return newError("argument to `pop` must be an ARRAY, got=%s", args[0].Type())
+			}
+
+			length := len(arr.Elements)
+
+			if length == 0 {
+				return newError("cannot `pop` from an empty array")
+			}
+
+			rest := make([]object.Object, length-1)
+			copy(rest, arr.Elements[:length-1])
+
+			return &object.Array{Elements: []object.Object{arr.Elements[length-1], &object.Array{Elements: rest}}}
+		},
+	},
+	// version() => {"version": "0.1.0", "strict_indexing": false, "trace": false, "features": [...]}
+	"version": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 0)
+			}
+
+			features := make([]object.Object, len(languageFeatures))
+
+			for i, feature := range languageFeatures {
+				features[i] = &object.String{Value: feature}
+			}
+
+			return newHash(map[string]object.Object{
+				"version":         &object.String{Value: VERSION},
+				"strict_indexing": nativeBoolToBooleanObject(StrictIndexing),
+				"trace":           nativeBoolToBooleanObject(Trace),
+				"features":        &object.Array{Elements: features},
+			})
+		},
+	},
+	"read_file": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			path, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `read_file` must be a STRING, got=%s", args[0].Type())
+			}
+
+			data, err := os.ReadFile(path.Value)
+
+			if err != nil {
+				return newError("could not read file: %s", err)
+			}
+
+			return &object.String{Value: string(data)}
+		},
+	},
+	// exit() or exit(code). Stop the program right away, the code default to 0
+	"exit": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+			}
+
+			code := int64(0)
+
+			if len(args) == 1 {
+				integer, ok := args[0].(*object.Integer)
+
+				if !ok {
+					return newError("argument to `exit` must be an INTEGER, got=%s", args[0].Type())
+				}
+
+				code = integer.Value
+			}
+
+			os.Stdout.Sync()
+			exit(int(code))
+
+			return NULL
+		},
+	},
+	// rotate([1, 2, 3, 4], 1) => [2, 3, 4, 1], a negative count rotate to the right
+	"rotate": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+			}
+
+			arr, ok := args[0].(*object.Array)
+
+			if !ok {
+				return newError("first argument to `rotate` must be an ARRAY, got=%s", args[0].Type())
+			}
+
+			count, ok := args[1].(*object.Integer)
+
+			if !ok {
+				return newError("second argument to `rotate` must be an INTEGER, got=%s", args[1].Type())
+			}
+
+			length := int64(len(arr.Elements))
+			rotated := make([]object.Object, 0, length)
+
+			if length == 0 {
+				return &object.Array{Elements: rotated}
+			}
+
+			// Normalize into [0, length) so oversized and negative counts wrap around
+			shift := ((count.Value % length) + length) % length
+
+			rotated = append(rotated, arr.Elements[shift:]...)
+			rotated = append(rotated, arr.Elements[:shift]...)
+
+			return &object.Array{Elements: rotated}
+		},
+	},
+	"trim": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `trim` must be a STRING, got=%s", args[0].Type())
+			}
+
+			return &object.String{Value: strings.TrimSpace(str.Value)}
+		},
+	},
+	"upper": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `upper` must be a STRING, got=%s", args[0].Type())
+			}
+
+			return &object.String{Value: strings.ToUpper(str.Value)}
+		},
+	},
+	"lower": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+			}
+
+			str, ok := args[0].(*object.String)
+
+			if !ok {
+				return newError("argument to `lower` must be a STRING, got=%s", args[0].Type())
+			}
+
+			return &object.String{Value: strings.ToLower(str.Value)}
+		},
+	},
+}
+
+// formatField render one `format` argument with a spec of the form [align][0][width], where
+// align is `<`, `>` or `^`. eg: `>10` right-align in 10 columns, `08` zero-pad to 8 digits
+func formatField(value object.Object, spec string) (string, *object.Error) {
+	text := value.Inspect()
+	_, isNumber := value.(*object.Integer)
+
+	if _, ok := value.(*object.Float); ok {
+		isNumber = true
+	}
+
+	// Numbers line up on the right by default, everything else on the left
+	align := byte('<')
+
+	if isNumber {
+		align = '>'
+	}
+
+	original := spec
+
+	if spec != "" && strings.IndexByte("<>^", spec[0]) >= 0 {
+		align, spec = spec[0], spec[1:]
+	}
+
+	zeroPad := strings.HasPrefix(spec, "0")
+	width := 0
+
+	if spec != "" {
+		n, err := strconv.Atoi(spec)
+
+		if err != nil || n < 0 {
+			return "", newError("invalid format spec `%s`", original)
+		}
+
+		// Same bound as string repetition, the padding is built with strings.Repeat too
+		if n > MAX_STRING_LENGTH {
+			return "", newError("format width %d exceeds the limit of %d", n, MAX_STRING_LENGTH)
+		}
+
+		width = n
+	}
+
+	padding := width - utf8.RuneCountInString(text)
+
+	if padding <= 0 {
+		return text, nil
+	}
+
+	if zeroPad {
+		// Keep the sign in front of the zeros. eg: -0042
+		sign := ""
+
+		if isNumber && strings.HasPrefix(text, "-") {
+			sign, text = "-", text[1:]
+		}
+
+		return sign + strings.Repeat("0", padding) + text, nil
+	}
+
+	switch align {
+	case '>':
+		return strings.Repeat(" ", padding) + text, nil
+	case '^':
+		left := padding / 2
+		return strings.Repeat(" ", left) + text + strings.Repeat(" ", padding-left), nil
+	default:
+		return text + strings.Repeat(" ", padding), nil
+	}
+}
+
+// splitWords break an identifier-like string into words on `_`, `-`, spaces and case changes,
+// keeping acronyms together. eg: "parseHTTPRequest_v2" => [parse HTTP Request v2]
+func splitWords(s string) []string {
+	words := []string{}
+	runes := []rune(s)
+	current := []rune{}
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = []rune{}
+		}
+	}
+
+	for i, r := range runes {
+		if r == '_' || r == '-' || unicode.IsSpace(r) {
+			flush()
+			continue
+		}
+
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := current[len(current)-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			// A new word start after a lowercase letter or digit, or at the last capital of an acronym
+			if !unicode.IsUpper(prev) || nextIsLower {
+				flush()
+			}
+		}
+
+		current = append(current, r)
+	}
+
+	flush()
+
+	return words
+}
+
+// iterate call fn with every element of an array or lazy range, the range elements are only
+// created as they are reached. Iteration stop at the first error, which is returned
+func iterate(name string, iterable object.Object, fn func(elem object.Object) object.Object) object.Object {
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		for _, elem := range iterable.Elements {
+			if result := fn(elem); isError(result) {
+				return result
+			}
+		}
+
+	case *object.Range:
+		for i := int64(0); i < iterable.Len(); i++ {
+			if result := fn(&object.Integer{Value: iterable.At(i)}); isError(result) {
+				return result
+			}
+		}
+
+	default:
+		return newError("first argument to `%s` must be an ARRAY or RANGE, got=%s", name, iterable.Type())
+	}
+
+	return nil
+}
+
+// writePretty write arrays and hashes one element per line, indented by their depth. Collections
+// already being written higher up print as `...` so cycles dont recurse forever
+func writePretty(out *strings.Builder, obj object.Object, depth int, seen map[object.Object]bool) {
+	indent := strings.Repeat("  ", depth+1)
+
+	switch obj := obj.(type) {
+	case *object.Array:
+		if seen[obj] {
+			out.WriteString("...")
+			return
+		}
+
+		if len(obj.Elements) == 0 {
+			out.WriteString("[]")
+			return
+		}
+
+		seen[obj] = true
+		out.WriteString("[\n")
+
+		for i, elem := range obj.Elements {
+			out.WriteString(indent)
+			writePretty(out, elem, depth+1, seen)
+
+			if i < len(obj.Elements)-1 {
+				out.WriteString(",")
+			}
+
+			out.WriteString("\n")
+		}
+
+		out.WriteString(strings.Repeat("  ", depth) + "]")
+		delete(seen, obj)
+
+	case *object.Hash:
+		if seen[obj] {
+			out.WriteString("...")
+			return
+		}
+
+		if len(obj.Pairs) == 0 {
+			out.WriteString("{}")
+			return
+		}
+
+		seen[obj] = true
+		out.WriteString("{\n")
+
+		pairs := obj.OrderedPairs()
+
+		for i, pair := range pairs {
+			out.WriteString(indent + pair.Key.Inspect() + ": ")
+			writePretty(out, pair.Value, depth+1, seen)
+
+			if i < len(pairs)-1 {
+				out.WriteString(",")
+			}
+
+			out.WriteString("\n")
+		}
+
+		out.WriteString(strings.Repeat("  ", depth) + "}")
+		delete(seen, obj)
+
+	default:
+		out.WriteString(obj.Inspect())
+	}
+}
+
+// deepSize count an object plus everything reachable from it: array elements and hash keys and
+// values. A collection reached twice, shared or through a cycle, is only counted the first time
+func deepSize(obj object.Object, seen map[object.Object]bool) int64 {
+	switch obj := obj.(type) {
+	case *object.Array:
+		if seen[obj] {
+			return 0
+		}
+
+		seen[obj] = true
+		size := int64(1)
+
+		for _, elem := range obj.Elements {
+			size += deepSize(elem, seen)
+		}
+
+		return size
+
+	case *object.Hash:
+		if seen[obj] {
+			return 0
+		}
+
+		seen[obj] = true
+		size := int64(1)
+
+		for _, pair := range obj.Pairs {
+			size += deepSize(pair.Key, seen) + deepSize(pair.Value, seen)
+		}
+
+		return size
+
+	default:
+		return 1
+	}
+}
+
+// rangeArgs validate the arguments shared by `range` and `xrange`: (end), (start, end) or (start, end, step)
+func rangeArgs(name string, args []object.Object) (*object.Range, *object.Error) {
+	if len(args) < 1 || len(args) > 3 {
+		return nil, newError("wrong number of arguments. got=%d, want=1 to 3", len(args))
+	}
+
+	bounds := make([]int64, len(args))
+
+	for i, arg := range args {
+		n, ok := arg.(*object.Integer)
+
+		if !ok {
+			return nil, newError("arguments to `%s` must be INTEGER, got=%s", name, arg.Type())
+		}
+
+		bounds[i] = n.Value
+	}
+
+	r := &object.Range{Start: 0, End: bounds[0], Step: 1}
+
+	if len(bounds) > 1 {
+		r.Start, r.End = bounds[0], bounds[1]
+	}
+
+	if len(bounds) > 2 {
+		r.Step = bounds[2]
+	}
+
+	if r.Step == 0 {
+		return nil, newError("step of `%s` must not be zero", name)
+	}
+
+	return r, nil
+}
+
+// arraySizeArgs validate the (array, positive size) arguments shared by `chunk` and `windows`
+func arraySizeArgs(name string, args []object.Object) (*object.Array, int, *object.Error) {
+	if len(args) != 2 {
+		return nil, 0, newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+	}
+
+	arr, ok := args[0].(*object.Array)
+
+	if !ok {
+		return nil, 0, newError("first argument to `%s` must be an ARRAY, got=%s", name, args[0].Type())
+	}
+
+	n, ok := args[1].(*object.Integer)
+
+	if !ok {
+		return nil, 0, newError("second argument to `%s` must be an INTEGER, got=%s", name, args[1].Type())
+	}
+
+	if n.Value <= 0 {
+		return nil, 0, newError("second argument to `%s` must be positive, got=%d", name, n.Value)
+	}
+
+	return arr, int(n.Value), nil
+}
+
+// regexArgs validate the (string, pattern) arguments shared by the regex builtins
+func regexArgs(name string, args []object.Object) (string, *regexp.Regexp, *object.Error) {
+	if len(args) != 2 {
+		return "", nil, newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+	}
+
+	str, ok := args[0].(*object.String)
+
+	if !ok {
+		return "", nil, newError("first argument to `%s` must be a STRING, got=%s", name, args[0].Type())
+	}
+
+	pattern, ok := args[1].(*object.String)
+
+	if !ok {
+		return "", nil, newError("second argument to `%s` must be a STRING, got=%s", name, args[1].Type())
+	}
+
+	re, err := regexp.Compile(pattern.Value)
+
+	if err != nil {
+		return "", nil, newError("invalid pattern %q: %s", pattern.Value, err)
+	}
+
+	return str.Value, re, nil
+}
+
+// newHash build a hash object with string keys, inserted in sorted order since a Go map has none
+func newHash(fields map[string]object.Object) *object.Hash {
+	hash := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair, len(fields))}
+	names := make([]string, 0, len(fields))
+
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		key := &object.String{Value: name}
+		hash.Set(key.HashKey(), object.HashPair{Key: key, Value: fields[name]})
+	}
+
+	return hash
+}
+
+// deepMerge return a new hash with `b` merged into `a`. Nested hashes are merged recursively,
+// any other value in `b` ( arrays included ) replace the one in `a`
+// The keys of `a` keep their position, new keys from `b` follow in their own order
+func deepMerge(a *object.Hash, b *object.Hash) (*object.Hash, *object.Error) {
+	merged := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair, len(a.Pairs))}
+
+	for _, pair := range a.OrderedPairs() {
+		merged.Set(pair.Key.(object.Hashable).HashKey(), pair)
+	}
+
+	for _, pair := range b.OrderedPairs() {
+		key := pair.Key.(object.Hashable).HashKey()
+		existing, ok := merged.Pairs[key]
+
+		if ok {
+			existingHash, isHash := existing.Value.(*object.Hash)
+			newHash, isNewHash := pair.Value.(*object.Hash)
+
+			if isHash && isNewHash {
+				nested, errObj := deepMerge(existingHash, newHash)
+
+				if errObj != nil {
+					return nil, errObj
+				}
+
+				merged.Set(key, object.HashPair{Key: pair.Key, Value: nested})
+				continue
+			}
+		}
+
+		merged.Set(key, pair)
+
+		// Nested hashes are never returned on their own, so check every level as it grow
+		if errObj := checkHashSize(len(merged.Pairs)); errObj != nil {
+			return nil, errObj
+		}
+	}
+
+	return merged, nil
+}
+
+// flattenInto add `val` into `flat` under the dotted `path`. eg: {"a": {"b": [1]}} => {"a.b[0]": 1}
+func flattenInto(flat *object.Hash, path string, val object.Object) {
+	switch val := val.(type) {
+	case *object.Hash:
+		if len(val.Pairs) > 0 {
+			for _, pair := range val.OrderedPairs() {
+				flattenInto(flat, path+"."+pair.Key.Inspect(), pair.Value)
+			}
+			return
+		}
+
+	case *object.Array:
+		if len(val.Elements) > 0 {
+			for i, elem := range val.Elements {
+				flattenInto(flat, fmt.Sprintf("%s[%d]", path, i), elem)
+			}
+			return
+		}
+	}
+
+	// Scalar and empty collection are kept as is
+	key := &object.String{Value: path}
+	flat.Set(key.HashKey(), object.HashPair{Key: key, Value: val})
+}
+
+// Builtins that call back into the evaluator are registered here, since
+// referencing `applyFunction` inside the `builtins` literal is an initialization cycle
+func init() {
+	builtins["scan"] = &object.Builtin{Fn: builtinScan}
+	builtins["partition"] = &object.Builtin{Fn: builtinPartition}
+	builtins["build"] = &object.Builtin{Fn: builtinBuild}
+	builtins["cond"] = &object.Builtin{Fn: builtinCond}
+	builtins["generate"] = &object.Builtin{Fn: builtinGenerate}
+	builtins["filter"] = &object.Builtin{Fn: builtinFilter}
+	builtins["reduce"] = &object.Builtin{Fn: builtinReduce}
+	builtins["map"] = &object.Builtin{Fn: builtinMap}
+	builtins["is_subset"] = &object.Builtin{Fn: builtinIsSubset}
+	builtins["equal"] = &object.Builtin{Fn: builtinEqual}
+	builtins["assert_eq"] = &object.Builtin{Fn: builtinAssertEq}
+	builtins["curry"] = &object.Builtin{Fn: builtinCurry}
+
+	envBuiltins[builtins["locals"]] = evalLocals
+	envBuiltins[builtins["max_depth"]] = evalMaxDepth
+	builtins["pipe_str"] = &object.Builtin{Fn: builtinPipeStr}
+	builtins["deep_contains"] = &object.Builtin{Fn: builtinDeepContains}
+}
+
+func builtinScan(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 3)
+	}
+
+	// scan([1, 2, 3], 0, fn(acc, x) { acc + x }) => [0, 1, 3, 6]
+	acc := args[1]
+	results := []object.Object{acc}
+
+	errObj := iterate("scan", args[0], func(elem object.Object) object.Object {
+		acc = applyFunction(args[2], []object.Object{acc, elem})
+
+		if isError(acc) {
+			return acc
+		}
+
+		results = append(results, acc)
+
+		// Check while building, a huge RANGE could exhaust memory before the result is returned
+		if errObj := checkArrayLength(len(results)); errObj != nil {
+			return errObj
+		}
+
+		return nil
+	})
+
+	if errObj != nil {
+		return errObj
+	}
+
+	return &object.Array{Elements: results}
+}
+
+func builtinPartition(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+	}
+
+	matching := []object.Object{}
+	notMatching := []object.Object{}
+
+	errObj := iterate("partition", args[0], func(elem object.Object) object.Object {
+		result := applyFunction(args[1], []object.Object{elem})
+
+		if isError(result) {
+			return result
+		}
+
+		if isTruthy(result) {
+			matching = append(matching, elem)
+		} else {
+			notMatching = append(notMatching, elem)
+		}
+
+		// The halves are nested in the result, so the check on the returned array would miss them
+		for _, half := range [][]object.Object{matching, notMatching} {
+			if errObj := checkArrayLength(len(half)); errObj != nil {
+				return errObj
+			}
+		}
+
+		return nil
+	})
+
+	if errObj != nil {
+		return errObj
+	}
+
+	return &object.Array{Elements: []object.Object{
+		&object.Array{Elements: matching},
+		&object.Array{Elements: notMatching},
+	}}
+}
+
+func builtinBuild(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 3)
+	}
+
+	n, ok := args[0].(*object.Integer)
+
+	if !ok {
+		return newError("first argument to `build` must be an INTEGER, got=%s", args[0].Type())
+	}
+
+	sep, ok := args[2].(*object.String)
+
+	if !ok {
+		return newError("third argument to `build` must be a STRING, got=%s", args[2].Type())
+	}
+
+	parts := []string{}
+
+	for i := int64(0); i < n.Value; i++ {
+		result := applyFunction(args[1], []object.Object{&object.Integer{Value: i}})
+
+		if isError(result) {
+			return result
+		}
+
+		str, ok := result.(*object.String)
+
+		if !ok {
+			return newError("callback to `build` must return a STRING, got=%s", result.Type())
+		}
+
+		parts = append(parts, str.Value)
+	}
+
+	return &object.String{Value: strings.Join(parts, sep.Value)}
+}
+
+func builtinCond(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+	}
+
+	pairs, ok := args[0].(*object.Array)
+
+	if !ok {
+		return newError("argument to `cond` must be an ARRAY, got=%s", args[0].Type())
+	}
+
+	// cond([[fn() { x > 0 }, fn() { "positive" }], [fn() { true }, fn() { "other" }]])
+	for _, elem := range pairs.Elements {
+		pair, ok := elem.(*object.Array)
+
+		if !ok || len(pair.Elements) != 2 {
+			return newError("`cond` expects [condition, value] pairs, got=%s", elem.Inspect())
+		}
+
+		matched := applyFunction(pair.Elements[0], []object.Object{})
+
+		if isError(matched) {
+			return matched
+		}
+
+		if isTruthy(matched) {
+			return applyFunction(pair.Elements[1], []object.Object{})
+		}
+	}
+
+	return NULL
+}
+
+func builtinGenerate(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+	}
+
+	n, ok := args[0].(*object.Integer)
+
+	if !ok {
+		return newError("first argument to `generate` must be an INTEGER, got=%s", args[0].Type())
+	}
+
+	if n.Value < 0 {
+		return newError("first argument to `generate` must not be negative, got=%d", n.Value)
+	}
+
+	if errObj := checkArrayLength(int(n.Value)); errObj != nil {
+		return errObj
+	}
+
+	// Grow as results come in, `n` is user input and sizing the slice from it could exhaust memory
+	results := []object.Object{}
+
+	for i := int64(0); i < n.Value; i++ {
+		result := applyFunction(args[1], []object.Object{&object.Integer{Value: i}})
+
+		if isError(result) {
+			return result
+		}
+
+		results = append(results, result)
+	}
+
+	return &object.Array{Elements: results}
+}
+
+func builtinFilter(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+	}
+
+	results := []object.Object{}
+
+	errObj := iterate("filter", args[0], func(elem object.Object) object.Object {
+		result := applyFunction(args[1], []object.Object{elem})
+
+		if isError(result) {
+			return result
+		}
+
+		if isTruthy(result) {
+			results = append(results, elem)
+		}
+
+		// Check while building, a huge RANGE could exhaust memory before the result is returned
+		if errObj := checkArrayLength(len(results)); errObj != nil {
+			return errObj
+		}
+
+		return nil
+	})
+
+	if errObj != nil {
+		return errObj
+	}
+
+	return &object.Array{Elements: results}
+}
+
+func builtinReduce(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 3)
+	}
+
+	// reduce([1, 2, 3], 0, fn(acc, x) { acc + x }) => 6
+	acc := args[1]
+
+	errObj := iterate("reduce", args[0], func(elem object.Object) object.Object {
+		acc = applyFunction(args[2], []object.Object{acc, elem})
+
+		if isError(acc) {
+			return acc
+		}
+
+		return nil
+	})
+
+	if errObj != nil {
+		return errObj
+	}
+
+	return acc
+}
+
+func builtinMap(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+	}
+
+	results := []object.Object{}
+
+	errObj := iterate("map", args[0], func(elem object.Object) object.Object {
+		result := applyFunction(args[1], []object.Object{elem})
+
+		if isError(result) {
+			return result
+		}
+
+		results = append(results, result)
+
+		// Check while building, a huge RANGE could exhaust memory before the result is returned
+		if errObj := checkArrayLength(len(results)); errObj != nil {
+			return errObj
+		}
+
+		return nil
+	})
+
+	if errObj != nil {
+		return errObj
+	}
+
+	return &object.Array{Elements: results}
+}
+
+func builtinIsSubset(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+	}
+
+	subset, ok := args[0].(*object.Hash)
+
+	if !ok {
+		return newError("first argument to `is_subset` must be a HASH, got=%s", args[0].Type())
+	}
+
+	superset, ok := args[1].(*object.Hash)
+
+	if !ok {
+		return newError("second argument to `is_subset` must be a HASH, got=%s", args[1].Type())
+	}
+
+	for hashKey, pair := range subset.Pairs {
+		other, ok := superset.Pairs[hashKey]
+
+		if !ok || !objectsEqual(pair.Value, other.Value) {
+			return FALSE
+		}
+	}
+
+	return TRUE
+}
+
+func builtinEqual(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+	}
+
+	equal, errObj := valuesEqual(args[0], args[1])
+
+	if errObj != nil {
+		return errObj
+	}
+
+	return nativeBoolToBooleanObject(equal)
+}
+
+func builtinAssertEq(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+	}
+
+	actual, expected := args[0], args[1]
+	equal, errObj := valuesEqual(actual, expected)
+
+	if errObj != nil {
+		return errObj
+	}
+
+	// Strings print without quotes, so name the types when they alone make the difference
+	if !equal && actual.Type() != expected.Type() {
+		return newError("assertion failed: expected=%s (%s), got=%s (%s)",
+			expected.Inspect(), expected.Type(), actual.Inspect(), actual.Type())
+	}
+
+	if !equal {
+		return newError("assertion failed: expected=%s, got=%s", expected.Inspect(), actual.Inspect())
+	}
+
+	return TRUE
+}
+
+// curry(fn(a, b, c) { a + b + c }, 3)(1)(2)(3) => 6
+func builtinCurry(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+	}
+
+	switch args[0].(type) {
+	case *object.Function, *object.Builtin:
+	default:
+		return newError("first argument to `curry` must be a FUNCTION, got=%s", args[0].Type())
+	}
+
+	arity, ok := args[1].(*object.Integer)
+
+	if !ok {
+		return newError("second argument to `curry` must be an INTEGER, got=%s", args[1].Type())
+	}
+
+	if arity.Value < 1 {
+		return newError("arity to `curry` must be at least 1, got=%d", arity.Value)
+	}
+
+	return curried(args[0], int(arity.Value), []object.Object{})
+}
+
+// curried return a builtin that take the next argument, calling `fn` once `arity` arguments
+// are collected. Each step copy the collected arguments so partial applications can be reused
+func curried(fn object.Object, arity int, collected []object.Object) object.Object {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("wrong number of arguments. got=%d, want=%d", len(args), 1)
+		}
+
+		next := make([]object.Object, len(collected)+1)
+		copy(next, collected)
+		next[len(collected)] = args[0]
+
+		if len(next) == arity {
+			return applyFunction(fn, next)
+		}
+
+		return curried(fn, arity, next)
+	}}
+}
+
+// pipe_str("  hi  ", [trim, upper]) => "HI". Every function take and return a string
+func builtinPipeStr(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+	}
+
+	str, ok := args[0].(*object.String)
+
+	if !ok {
+		return newError("first argument to `pipe_str` must be a STRING, got=%s", args[0].Type())
+	}
+
+	fns, ok := args[1].(*object.Array)
+
+	if !ok {
+		return newError("second argument to `pipe_str` must be an ARRAY, got=%s", args[1].Type())
+	}
+
+	result := object.Object(str)
+
+	for i, fn := range fns.Elements {
+		result = applyFunction(fn, []object.Object{result})
+
+		if isError(result) {
+			return result
+		}
+
+		if result.Type() != object.STRING_OBJ {
+			return newError("function %d in `pipe_str` must return a STRING, got=%s", i, result.Type())
+		}
+	}
+
+	return result
+}
+
+// deep_contains({"a": [1, {"b": 2}]}, {"b": 2}) => true
+func builtinDeepContains(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 2)
+	}
+
+	switch args[0].(type) {
+	case *object.Array, *object.Hash:
+	default:
+		return newError("first argument to `deep_contains` must be an ARRAY or HASH, got=%s", args[0].Type())
+	}
+
+	found, errObj := deepContains(args[0], args[1], map[object.Object]bool{})
+
+	if errObj != nil {
+		return errObj
+	}
+
+	return nativeBoolToBooleanObject(found)
+}
+
+// deepContains report whether `needle` is structurally equal to any element of `haystack`, or to
+// anything nested inside them. Hashes are searched by value. Every collection is only visited once
+// so self-referencing arrays and hashes dont recurse forever
+func deepContains(haystack object.Object, needle object.Object, seen map[object.Object]bool) (bool, *object.Error) {
+	if seen[haystack] {
+		return false, nil
+	}
+
+	var children []object.Object
+
+	switch haystack := haystack.(type) {
+	case *object.Array:
+		children = haystack.Elements
+
+	case *object.Hash:
+		for _, pair := range haystack.OrderedPairs() {
+			children = append(children, pair.Value)
+		}
+
+	default:
+		return false, nil
+	}
+
+	seen[haystack] = true
+
+	for _, child := range children {
+		// valuesEqual is cycle safe too, so a self-referencing child or needle compare fine
+		equal, errObj := valuesEqual(child, needle)
+
+		if errObj != nil {
+			return false, errObj
+		}
+
+		if equal {
+			return true, nil
+		}
+
+		if found, errObj := deepContains(child, needle, seen); errObj != nil || found {
+			return found, errObj
+		}
+	}
+
+	return false, nil
 }
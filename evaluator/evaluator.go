@@ -4,15 +4,82 @@ import (
 	"Monkey/ast"
 	"Monkey/object"
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
 )
 
 var (
-	NULL  = &object.Null{}
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	NULL     = &object.Null{}
+	TRUE     = &object.Boolean{Value: true}
+	FALSE    = &object.Boolean{Value: false}
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
+)
+
+// MAX_STRING_LENGTH cap the length in bytes of a string built by repetition. eg: "ab" * n
+const MAX_STRING_LENGTH = 1 << 28
+
+// StrictIndexing make out of range indices and missing hash keys an error instead of `NULL`
+var StrictIndexing = false
+
+// MaxArrayLength and MaxHashSize cap how big a single array or hash can grow so untrusted
+// scripts cannot exhaust memory, zero mean no limit
+var (
+	MaxArrayLength = 0
+	MaxHashSize    = 0
+)
+
+// Trace print every node entering `Eval` to TraceOut, indented by how deep it is in the tree
+var (
+	Trace              = false
+	TraceOut io.Writer = os.Stdout
 )
 
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	if Trace {
+		state := env.State()
+		fmt.Fprintf(TraceOut, "%s%s\n", strings.Repeat("  ", state.TraceDepth), strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast."))
+		state.TraceDepth++
+		defer func() { state.TraceDepth-- }()
+	}
+
+	result := eval(node, env)
+
+	// The innermost node that know its position tag the error, outer nodes leave it alone
+	if errObj, ok := result.(*object.Error); ok && errObj.Line == 0 {
+		errObj.Line = nodeLine(node)
+	}
+
+	return result
+}
+
+// nodeLine return the source line of the nodes that carry a meaningful position, or 0
+func nodeLine(node ast.Node) int {
+	switch node := node.(type) {
+	case *ast.LetStatement:
+		return node.Token.Line
+	case *ast.ReturnStatement:
+		return node.Token.Line
+	case *ast.ExpressionStatement:
+		return node.Token.Line
+	case *ast.Identifier:
+		return node.Token.Line
+	case *ast.PrefixExpression:
+		return node.Token.Line
+	case *ast.InfixExpression:
+		return node.Token.Line
+	case *ast.CallExpression:
+		return node.Token.Line
+	case *ast.IndexExpression:
+		return node.Token.Line
+	default:
+		return 0
+	}
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 
 	case *ast.Program:
@@ -27,9 +94,15 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 
+	case *ast.NullLiteral:
+		return NULL
+
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 
@@ -55,11 +128,29 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return right
 		}
 
+		if errObj := checkDivisor(node, left, right); errObj != nil {
+			return errObj
+		}
+
 		return evalInfixExpression(node.Operator, left, right)
 
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
 
+	case *ast.TernaryExpression:
+		condition := Eval(node.Condition, env)
+
+		if isError(condition) {
+			return condition
+		}
+
+		// Only the chosen branch is evaluated
+		if isTruthy(condition) {
+			return Eval(node.Consequence, env)
+		}
+
+		return Eval(node.Alternative, env)
+
 	case *ast.ReturnStatement:
 		// Evaluate the return value expression
 		val := Eval(node.ReturnValue, env)
@@ -73,6 +164,21 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		// We check in `evalStatements`
 		return &object.ReturnValue{Value: val}
 
+	case *ast.WhileExpression:
+		return evalWhileExpression(node, env)
+
+	case *ast.ForExpression:
+		return evalForExpression(node, env)
+
+	case *ast.ForInStatement:
+		return evalForInStatement(node, env)
+
+	case *ast.BreakStatement:
+		return BREAK
+
+	case *ast.ContinueStatement:
+		return CONTINUE
+
 	case *ast.LetStatement:
 		val := Eval(node.Value, env)
 
@@ -106,6 +212,10 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return args[0]
 		}
 
+		if builtin, ok := fn.(*object.Builtin); ok && envBuiltins[builtin] != nil {
+			return envBuiltins[builtin](args, env)
+		}
+
 		return applyFunction(fn, args)
 
 	case *ast.StringLiteral:
@@ -118,6 +228,10 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return elements[0] // If there is an error, return an error object
 		}
 
+		if errObj := checkArrayLength(len(elements)); errObj != nil {
+			return errObj
+		}
+
 		return &object.Array{Elements: elements}
 
 	case *ast.IndexExpression:
@@ -135,19 +249,38 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 
 		return evalIndexExpression(left, index)
 
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
+
 	case *ast.AssignmentExpression:
+		if node.Token.Literal != "=" {
+			return evalConditionalAssignment(node, env)
+		}
+
 		val := Eval(node.Value, env)
 
 		if isError(val) {
 			return val
 		}
 
-		if !env.IsKey(node.Name.Value) {
+		if !env.Assign(node.Name.Value, val) {
 			return newError("identifier not found `%s`", node.Name.Value)
 		}
 
-		env.Set(node.Name.Value, val)
-		return nil
+		// Evaluate to the assigned value so assignment can be chained. eg: a = b = 0
+		return val
+
+	case *ast.IndexAssignmentExpression:
+		return evalIndexAssignment(node, env)
+
+	case *ast.DestructuringAssignment:
+		return evalDestructuringAssignment(node, env)
+
+	case *ast.PipeExpression:
+		return evalPipeExpression(node, env)
+
+	case *ast.SequenceExpression:
+		return evalSequenceExpression(node, env)
 
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
@@ -159,6 +292,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 func evalProgram(statements []ast.Statement, env *object.Environment) object.Object {
 	var result object.Object
 
+	state := env.State()
+	state.CallDepth, state.MaxCallDepth = 0, 0
+
 	for _, stmt := range statements {
 		result = Eval(stmt, env)
 
@@ -168,6 +304,8 @@ func evalProgram(statements []ast.Statement, env *object.Environment) object.Obj
 			return result.Value
 		case *object.Error:
 			return result
+		case *object.Break, *object.Continue:
+			return newError("`%s` outside of loop", result.Inspect())
 		}
 	}
 
@@ -185,11 +323,13 @@ func evalStatements(statements []ast.Statement, env *object.Environment) object.
 		// the `object.ReturnValue` contain and cant be detected in `evalProgram`
 
 		// `object.ERROR_OBJ` just to terminate execution in case there is any error
+		// `object.BREAK_OBJ` and `object.CONTINUE_OBJ` bubble up to the enclosing loop
 
 		if result != nil {
 			resultType := result.Type()
 
-			if resultType == object.RETURN_VALUE_OBJ || resultType == object.ERROR_OBJ {
+			if resultType == object.RETURN_VALUE_OBJ || resultType == object.ERROR_OBJ ||
+				resultType == object.BREAK_OBJ || resultType == object.CONTINUE_OBJ {
 				return result
 			}
 		}
@@ -231,22 +371,90 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperator(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+	if operand := evalNumericOperand(right); isError(operand) {
+		return operand
+	}
+
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: -right.Value}
+
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
+}
+
+// isArithmeticOperator report whether the infix operator does math on its operands
+func isArithmeticOperator(operator string) bool {
+	switch operator {
+	case "+", "-", "*", "/", "%", "**", "<<", ">>", ">>>":
+		return true
+	default:
+		return false
+	}
+}
 
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
+// evalNumericOperand return the operand unchanged, or the uniform error every arithmetic path
+// report for the types that never take part in math
+func evalNumericOperand(operand object.Object) object.Object {
+	switch operand.Type() {
+	case object.BOOLEAN_OBJ, object.NULL_OBJ:
+		return newError("type error: cannot use %s in arithmetic", operand.Type())
+	default:
+		return operand
+	}
 }
 
 func evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	if isArithmeticOperator(operator) {
+		for _, operand := range []object.Object{left, right} {
+			if operand := evalNumericOperand(operand); isError(operand) {
+				return operand
+			}
+		}
+	}
+
 	switch {
+	case operator == "in":
+		return evalInExpression(left, right)
+
+	case operator == "not in":
+		result := evalInExpression(left, right)
+
+		if isError(result) {
+			return result
+		}
+
+		return nativeBoolToBooleanObject(result == FALSE)
+
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
 
+	// At least one side is a float here, so the integer side is promoted
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
+
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
 
+	case left.Type() == object.STRING_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalStringIntegerInfixExpression(operator, left, right)
+
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.STRING_OBJ:
+		// Repetition is commutative. eg: 3 * "x" == "x" * 3
+		if operator == "*" {
+			return evalStringIntegerInfixExpression(operator, right, left)
+		}
+
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+
+	case left.Type() == object.ARRAY_OBJ && right.Type() == object.ARRAY_OBJ,
+		left.Type() == object.HASH_OBJ && right.Type() == object.HASH_OBJ:
+		return evalCollectionInfixExpression(operator, left, right)
+
 	case operator == "==": // For boolean comoparison
 		return nativeBoolToBooleanObject(left == right) // Pointer comparison
 
@@ -261,6 +469,67 @@ func evalInfixExpression(operator string, left object.Object, right object.Objec
 	}
 }
 
+func evalCollectionInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	if operator != "==" && operator != "!=" {
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	equal, errObj := valuesEqual(left, right)
+
+	if errObj != nil {
+		return errObj
+	}
+
+	return nativeBoolToBooleanObject(equal == (operator == "=="))
+}
+
+// checkArrayLength report an error when an array of `length` elements exceed MaxArrayLength
+func checkArrayLength(length int) *object.Error {
+	if MaxArrayLength > 0 && length > MaxArrayLength {
+		return newError("array length %d exceeds the limit of %d", length, MaxArrayLength)
+	}
+
+	return nil
+}
+
+// checkHashSize report an error when a hash of `size` pairs exceed MaxHashSize
+func checkHashSize(size int) *object.Error {
+	if MaxHashSize > 0 && size > MaxHashSize {
+		return newError("hash size %d exceeds the limit of %d", size, MaxHashSize)
+	}
+
+	return nil
+}
+
+// checkCollectionSize apply checkArrayLength or checkHashSize to an array or hash result
+func checkCollectionSize(obj object.Object) *object.Error {
+	switch obj := obj.(type) {
+	case *object.Array:
+		return checkArrayLength(len(obj.Elements))
+	case *object.Hash:
+		return checkHashSize(len(obj.Pairs))
+	default:
+		return nil
+	}
+}
+
+// checkDivisor catch division and modulo by zero before they reach Go, where integers would panic
+// and floats would silently turn into `+Inf` or `NaN`
+func checkDivisor(node *ast.InfixExpression, left object.Object, right object.Object) *object.Error {
+	if !isNumber(left) || !isNumber(right) || toFloat(right) != 0 {
+		return nil
+	}
+
+	switch node.Operator {
+	case "/":
+		return newNodeError(node, "division by zero")
+	case "%":
+		return newNodeError(node, "modulo by zero")
+	default:
+		return nil
+	}
+}
+
 func evalIntegerInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
@@ -278,12 +547,114 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 	case "/":
 		return &object.Integer{Value: leftVal / rightVal}
 
+	case "%":
+		return &object.Integer{Value: leftVal % rightVal}
+
+	case "**":
+		if rightVal < 0 {
+			return newError("negative exponent: %d ** %d", leftVal, rightVal)
+		}
+
+		result, ok := intPow(leftVal, rightVal)
+
+		if !ok {
+			return newError("integer overflow: %d ** %d", leftVal, rightVal)
+		}
+
+		return &object.Integer{Value: result}
+
+	case "<<", ">>", ">>>":
+		if rightVal < 0 {
+			return newError("negative shift count: %d %s %d", leftVal, operator, rightVal)
+		}
+
+		switch operator {
+		case "<<":
+			return &object.Integer{Value: leftVal << rightVal}
+		case ">>":
+			return &object.Integer{Value: leftVal >> rightVal}
+		default:
+			// Treat the bits as unsigned so the sign bit is not extended
+			return &object.Integer{Value: int64(uint64(leftVal) >> rightVal)}
+		}
+
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// isNumber report whether the object is an INTEGER or a FLOAT
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+// toFloat return the value of an INTEGER or FLOAT as float64
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.Float:
+		return obj.Value
+	default:
+		return 0
+	}
+}
+
+// evalFloatInfixExpression handle every mix of INTEGER and FLOAT where at least one side is a
+// FLOAT. The result is always a FLOAT, so division no longer truncate
+func evalFloatInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+
+	case "/":
+		return &object.Float{Value: leftVal / rightVal}
+
+	case "%":
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+
+	case "**":
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
+
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
 
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 
@@ -291,10 +662,50 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 		return nativeBoolToBooleanObject(leftVal != rightVal)
 
 	default:
+		// Bit shifts only make sense on integers
 		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
+// intPow raise base to a non negative exponent by squaring, so it take O(log exp) steps. The
+// second result is false when the power does not fit in an int64
+func intPow(base int64, exp int64) (int64, bool) {
+	result := int64(1)
+
+	for exp > 0 {
+		if exp&1 == 1 {
+			if mulOverflows(result, base) {
+				return 0, false
+			}
+
+			result *= base
+		}
+
+		exp >>= 1
+
+		// Only square when another bit remain, the last square would be unused and might overflow
+		if exp > 0 {
+			if mulOverflows(base, base) {
+				return 0, false
+			}
+
+			base *= base
+		}
+	}
+
+	return result, true
+}
+
+// mulOverflows report whether a * b overflow int64
+func mulOverflows(a int64, b int64) bool {
+	if a == 0 || b == 0 {
+		return false
+	}
+
+	c := a * b
+	return c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64)
+}
+
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 
@@ -312,10 +723,92 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	}
 }
 
+func evalWhileExpression(we *ast.WhileExpression, env *object.Environment) object.Object {
+	var result object.Object = NULL
+
+	for {
+		condition := Eval(we.Condition, env)
+
+		// Prevent error object being pass around.. If its error, return immdediately
+		if isError(condition) {
+			return condition
+		}
+
+		if !isTruthy(condition) {
+			return result
+		}
+
+		evaluated := Eval(we.Body, env)
+
+		if evaluated == nil {
+			continue
+		}
+
+		switch evaluated.Type() {
+		case object.BREAK_OBJ:
+			return result
+		case object.CONTINUE_OBJ:
+			continue
+		case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+			return evaluated
+		}
+
+		result = evaluated
+	}
+}
+
+func evalForExpression(fe *ast.ForExpression, env *object.Environment) object.Object {
+	var result object.Object = NULL
+
+	// Variables declared in `init` are scoped to the loop
+	loopEnv := object.NewEnclosedEnvironment(env)
+
+	if fe.Init != nil {
+		if init := Eval(fe.Init, loopEnv); isError(init) {
+			return init
+		}
+	}
+
+	for {
+		if fe.Condition != nil {
+			condition := Eval(fe.Condition, loopEnv)
+
+			if isError(condition) {
+				return condition
+			}
+
+			if !isTruthy(condition) {
+				return result
+			}
+		}
+
+		evaluated := Eval(fe.Body, loopEnv)
+
+		if evaluated != nil {
+			switch evaluated.Type() {
+			case object.BREAK_OBJ:
+				return result
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+				return evaluated
+			case object.CONTINUE_OBJ:
+				// Still run the post expression below
+			default:
+				result = evaluated
+			}
+		}
+
+		if fe.Post != nil {
+			if post := Eval(fe.Post, loopEnv); isError(post) {
+				return post
+			}
+		}
+	}
+}
+
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
 	// First search the identifier in current environment and its outer environment and etc
-	// If its still not found, try search from builtins, if still not found, return and error
-	// indicating identifier is not found
+	// If its still not found, try search from the builtins registered on this interpreter, then
+	// the default builtins, if still not found, return and error indicating identifier is not found
 
 	obj, ok := env.Get(node.Value)
 
@@ -323,6 +816,12 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return obj
 	}
 
+	custom, ok := env.Builtin(node.Value)
+
+	if ok {
+		return custom
+	}
+
 	obj, ok = builtins[node.Value]
 
 	if ok {
@@ -349,6 +848,11 @@ func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// newNodeError is newError followed by the source of the failing expression. eg: division by zero in (a / b)
+func newNodeError(node ast.Node, format string, a ...interface{}) *object.Error {
+	return newError(format+" in %s", append(a, node.String())...)
+}
+
 func isError(obj object.Object) bool {
 	return obj != nil && obj.Type() == object.ERROR_OBJ
 }
@@ -370,6 +874,28 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return args
 }
 
+// envBuiltins are the builtins needing the caller environment, which is not something builtins
+// receive. Calls to them are intercepted in the `*ast.CallExpression` case
+var envBuiltins = map[*object.Builtin]func(args []object.Object, env *object.Environment) object.Object{}
+
+// evalMaxDepth return the deepest function call nesting of the running program
+func evalMaxDepth(args []object.Object, env *object.Environment) object.Object {
+	if len(args) != 0 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 0)
+	}
+
+	return &object.Integer{Value: int64(env.State().MaxCallDepth)}
+}
+
+// evalLocals return a hash of the bindings in the current scope, keyed by name
+func evalLocals(args []object.Object, env *object.Environment) object.Object {
+	if len(args) != 0 {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), 0)
+	}
+
+	return newHash(env.Locals())
+}
+
 func applyFunction(_fn object.Object, args []object.Object) object.Object {
 
 	// Build function params
@@ -394,15 +920,43 @@ func applyFunction(_fn object.Object, args []object.Object) object.Object {
 	switch fn := _fn.(type) {
 
 	case *object.Function:
+		// Callbacks handed to builtins are called with whatever the builtin supply, so check here
+		if len(args) != len(fn.Parameters) {
+			return newError("wrong number of arguments. got=%d, want=%d", len(args), len(fn.Parameters))
+		}
+
+		// The function environment lead back to the interpreter it was defined in
+		state := fn.Env.State()
+		state.CallDepth++
+
+		if state.CallDepth > state.MaxCallDepth {
+			state.MaxCallDepth = state.CallDepth
+		}
+
 		extendedEnv := extendedFunctionEnv(fn, args)
 		evaluated := Eval(fn.Body, extendedEnv)
+
+		state.CallDepth--
+
+		// Loop control cannot escape the function body
+		if evaluated == BREAK || evaluated == CONTINUE {
+			return newError("`%s` outside of loop", evaluated.Inspect())
+		}
+
 		return unwrapReturnValue(evaluated)
 
 	case *object.Builtin:
 		// Call directly since this builtin is `golang` code
-		return fn.Fn(args...)
+		result := fn.Fn(args...)
 
-	default:
+		// Every builtin building an array or hash is held to the same limits as literals
+		if errObj := checkCollectionSize(result); errObj != nil {
+			return errObj
+		}
+
+		return result
+
+	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
@@ -425,23 +979,67 @@ func unwrapReturnValue(obj object.Object) object.Object {
 		return returnVal.Value
 	}
 
+	// Function body is empty or end with a statement that produce no value ( eg: `let` )
+	// so the implicit return value is `NULL`
+	if obj == nil {
+		return NULL
+	}
+
 	return obj
 }
 
 func evalStringInfixExpression(operator string, left object.Object, right object.Object) object.Object {
-	if operator != "+" {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	// Comparison is lexicographic by bytes, so it is case sensitive. eg: "Z" < "a"
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
 		return &object.Error{Message: fmt.Sprintf("unknown operator: %s %s %s", left.Type(), operator, right.Type())}
 	}
+}
 
-	leftStr := left.(*object.String)
-	rightStr := right.(*object.String)
-	return &object.String{Value: leftStr.Value + rightStr.Value}
+func evalStringIntegerInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	if operator != "*" {
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	str := left.(*object.String).Value
+	count := right.(*object.Integer).Value
+
+	if count <= 0 || str == "" {
+		return &object.String{Value: ""}
+	}
+
+	// Divide instead of multiply so a huge count cannot overflow before the check
+	if count > int64(MAX_STRING_LENGTH/len(str)) {
+		return newError("string repetition too long: %d bytes * %d exceeds the limit of %d bytes",
+			len(str), count, MAX_STRING_LENGTH)
+	}
+
+	return &object.String{Value: strings.Repeat(str, int(count))}
 }
 
 func evalIndexExpression(left object.Object, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
@@ -455,18 +1053,134 @@ func evalArrayIndexExpression(left object.Object, index object.Object) object.Ob
 	idx := index.(*object.Integer).Value
 	max := len(arr) - 1
 
-	if idx < 0 || int(idx) > max {
+	pos := idx
+
+	// Negative index count from the end. eg: arr[-1] is the last element
+	if pos < 0 {
+		pos += int64(len(arr))
+	}
+
+	if pos < 0 || int(pos) > max {
+		if StrictIndexing {
+			return newError("index out of range: %d", idx)
+		}
+
+		return NULL
+	}
+
+	return arr[pos]
+}
+
+func evalStringIndexExpression(left object.Object, index object.Object) object.Object {
+	str := left.(*object.String).Value
+	idx := index.(*object.Integer).Value
+	max := len(str) - 1
+
+	pos := idx
+
+	// Negative index count from the end. eg: str[-1] is the last character
+	if pos < 0 {
+		pos += int64(len(str))
+	}
+
+	if pos < 0 || int(pos) > max {
+		if StrictIndexing {
+			return newError("index out of range: %d", idx)
+		}
+
 		return NULL
 	}
 
-	return arr[idx]
+	// Strings are indexed by byte like `len` and slicing, so str[i] == str[i:i + 1]
+	return &object.String{Value: str[pos : pos+1]}
+}
+
+func evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+
+	if isError(left) {
+		return left
+	}
+
+	var length int
+
+	switch left := left.(type) {
+	case *object.Array:
+		length = len(left.Elements)
+	case *object.String:
+		length = len(left.Value)
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+
+	low, err := evalSliceBound(node.Low, env, 0)
+
+	if err != nil {
+		return err
+	}
+
+	high, err := evalSliceBound(node.High, env, length)
+
+	if err != nil {
+		return err
+	}
+
+	// Clamp out of bound ends, reversed range produce an empty result
+	low = clamp(low, 0, length)
+	high = clamp(high, 0, length)
+
+	if low > high {
+		low = high
+	}
+
+	if arr, ok := left.(*object.Array); ok {
+		elements := make([]object.Object, high-low)
+		copy(elements, arr.Elements[low:high])
+		return &object.Array{Elements: elements}
+	}
+
+	return &object.String{Value: left.(*object.String).Value[low:high]}
+}
+
+func clamp(val int, min int, max int) int {
+	if val < min {
+		return min
+	}
+
+	if val > max {
+		return max
+	}
+
+	return val
+}
+
+func evalSliceBound(node ast.Expression, env *object.Environment, defaultVal int) (int, *object.Error) {
+	if node == nil {
+		return defaultVal, nil
+	}
+
+	bound := Eval(node, env)
+
+	if err, ok := bound.(*object.Error); ok {
+		return 0, err
+	}
+
+	integer, ok := bound.(*object.Integer)
+
+	if !ok {
+		return 0, newError("slice bound must be an INTEGER, got=%s", bound.Type())
+	}
+
+	return int(integer.Value), nil
 }
 
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	hash := &object.Hash{}
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+
+	// Walk the keys in source order so the hash remember it
+	for _, k := range node.Keys {
+		v := node.Pairs[k]
 
-	for k, v := range node.Pairs {
 		// Get key
 		key := Eval(k, env)
 
@@ -487,10 +1201,13 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
 			return val
 		}
 
-		pairs[hashKey.HashKey()] = object.HashPair{Key: key, Value: val}
+		hash.Set(hashKey.HashKey(), object.HashPair{Key: key, Value: val})
+	}
+
+	if errObj := checkHashSize(len(hash.Pairs)); errObj != nil {
+		return errObj
 	}
 
-	hash.Pairs = pairs
 	return hash
 }
 
@@ -506,8 +1223,457 @@ func evalHashIndexExpression(left object.Object, index object.Object) object.Obj
 	hashPair, ok := hash.Pairs[key.HashKey()]
 
 	if !ok {
+		if StrictIndexing {
+			return newError("key not found: %s", index.Inspect())
+		}
+
 		return NULL
 	}
 
 	return hashPair.Value
 }
+
+func evalIndexAssignment(node *ast.IndexAssignmentExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+
+	if isError(left) {
+		return left
+	}
+
+	index := Eval(node.Index, env)
+
+	if isError(index) {
+		return index
+	}
+
+	val := Eval(node.Value, env)
+
+	if isError(val) {
+		return val
+	}
+
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		// Mutate the slot in place so every binding of this array see the change
+		arr := left.(*object.Array).Elements
+		idx := index.(*object.Integer).Value
+		pos := idx
+
+		if pos < 0 {
+			pos += int64(len(arr))
+		}
+
+		if pos < 0 || pos >= int64(len(arr)) {
+			return newError("index out of range: %d", idx)
+		}
+
+		arr[pos] = val
+
+	case left.Type() == object.HASH_OBJ:
+		key, ok := index.(object.Hashable)
+
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+
+		hash := left.(*object.Hash)
+
+		// Only a new key grow the hash, overwriting is always allowed
+		if _, exists := hash.Pairs[key.HashKey()]; !exists {
+			if errObj := checkHashSize(len(hash.Pairs) + 1); errObj != nil {
+				return errObj
+			}
+		}
+
+		// Insert or overwrite in place so every binding of this hash see the change
+		hash.Set(key.HashKey(), object.HashPair{Key: index, Value: val})
+
+	default:
+		return newError("index assignment not supported: %s[%s]", left.Type(), index.Type())
+	}
+
+	// Evaluate to the assigned value like a plain assignment
+	return val
+}
+
+func evalDestructuringAssignment(node *ast.DestructuringAssignment, env *object.Environment) object.Object {
+	// Evaluate the whole right side first, so `[a, b] = [b, a]` swap correctly
+	val := Eval(node.Value, env)
+
+	if isError(val) {
+		return val
+	}
+
+	arr, ok := val.(*object.Array)
+
+	if !ok {
+		return newError("cannot destructure %s", val.Type())
+	}
+
+	if len(arr.Elements) != len(node.Names) {
+		return newError("destructuring mismatch. got=%d values, want=%d", len(arr.Elements), len(node.Names))
+	}
+
+	for _, name := range node.Names {
+		if !env.IsKey(name.Value) {
+			return newError("identifier not found `%s`", name.Value)
+		}
+	}
+
+	for i, name := range node.Names {
+		env.Assign(name.Value, arr.Elements[i])
+	}
+
+	return val
+}
+
+func evalSequenceExpression(node *ast.SequenceExpression, env *object.Environment) object.Object {
+	var result object.Object
+
+	// Evaluate from left to right, the value of the sequence is the last expression
+	for _, exp := range node.Expressions {
+		result = Eval(exp, env)
+
+		if isError(result) {
+			return result
+		}
+	}
+
+	return result
+}
+
+func evalForInStatement(node *ast.ForInStatement, env *object.Environment) object.Object {
+	iterable := Eval(node.Iterable, env)
+
+	if isError(iterable) {
+		return iterable
+	}
+
+	// Each iteration run in its own scope so closures capture the current element
+	runBody := func(key object.Object, val object.Object) (object.Object, bool) {
+		loopEnv := object.NewEnclosedEnvironment(env)
+
+		if node.Key != nil {
+			loopEnv.Set(node.Key.Value, key)
+		}
+
+		loopEnv.Set(node.Value.Value, val)
+
+		result := Eval(node.Body, loopEnv)
+
+		if result != nil {
+			switch result.Type() {
+			case object.BREAK_OBJ:
+				return nil, false
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+				return result, false
+			}
+		}
+
+		return nil, true
+	}
+
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		for i, elem := range iterable.Elements {
+			if result, ok := runBody(&object.Integer{Value: int64(i)}, elem); !ok {
+				return result
+			}
+		}
+
+	case *object.Range:
+		for i := int64(0); i < iterable.Len(); i++ {
+			if result, ok := runBody(&object.Integer{Value: i}, &object.Integer{Value: iterable.At(i)}); !ok {
+				return result
+			}
+		}
+
+	case *object.Hash:
+		for _, pair := range iterable.OrderedPairs() {
+			key, val := pair.Key, pair.Value
+
+			// Single identifier iterate over the keys. eg: for (k in hash)
+			if node.Key == nil {
+				val = pair.Key
+			}
+
+			if result, ok := runBody(key, val); !ok {
+				return result
+			}
+		}
+
+	default:
+		return newError("cannot iterate over %s", iterable.Type())
+	}
+
+	return nil
+}
+
+func evalInExpression(left object.Object, right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Array:
+		for _, elem := range right.Elements {
+			if objectsEqual(left, elem) {
+				return TRUE
+			}
+		}
+
+		return FALSE
+
+	case *object.Hash:
+		key, ok := left.(object.Hashable)
+
+		if !ok {
+			return newError("unusable as hash key: %s", left.Type())
+		}
+
+		_, ok = right.Pairs[key.HashKey()]
+		return nativeBoolToBooleanObject(ok)
+
+	case *object.String:
+		str, ok := left.(*object.String)
+
+		if !ok {
+			return newError("type mismatch: %s in %s", left.Type(), right.Type())
+		}
+
+		return nativeBoolToBooleanObject(strings.Contains(right.Value, str.Value))
+
+	case *object.Range:
+		n, ok := left.(*object.Integer)
+
+		if !ok {
+			return FALSE
+		}
+
+		// Check the bounds, then whether it land on a step instead of walking the range. The
+		// offset is measured in uint64 like `Range.Len` since it can overflow int64. eg: 7 in xrange(1, 10, 3)
+		var offset, step uint64
+
+		switch {
+		case right.Step > 0 && n.Value >= right.Start && n.Value < right.End:
+			offset, step = uint64(n.Value)-uint64(right.Start), uint64(right.Step)
+
+		case right.Step < 0 && n.Value <= right.Start && n.Value > right.End:
+			offset, step = uint64(right.Start)-uint64(n.Value), -uint64(right.Step)
+
+		default:
+			return FALSE
+		}
+
+		return nativeBoolToBooleanObject(offset%step == 0)
+
+	default:
+		return newError("unknown operator: %s in %s", left.Type(), right.Type())
+	}
+}
+
+// EQ_FUNCTION_KEY is the hash key of a user defined equality. eg: {"__eq": fn(a, b) { a["id"] == b["id"] }}
+const EQ_FUNCTION_KEY = "__eq"
+
+// objectsEqual follow the `==` semantic, an error raised by a user defined equality count as not equal
+func objectsEqual(left object.Object, right object.Object) bool {
+	equal, errObj := valuesEqual(left, right)
+	return errObj == nil && equal
+}
+
+// valuesEqual compare numbers and strings by value, INTEGER against FLOAT included, arrays and hashes
+// structurally, and everything else by identity. A hash holding an `__eq` function decide for itself
+// how it compare to another hash
+func valuesEqual(left object.Object, right object.Object) (bool, *object.Error) {
+	return valuesEqualSeen(left, right, map[[2]object.Object]bool{})
+}
+
+// valuesEqualSeen is valuesEqual remembering the pairs of collections already under comparison.
+// Meeting a pair again mean both sides loop back the same way, so it count as equal instead of
+// recursing forever. eg: let a = [1]; a[0] = a; let b = [1]; b[0] = b; a == b
+func valuesEqualSeen(left object.Object, right object.Object, seen map[[2]object.Object]bool) (bool, *object.Error) {
+	// Same rule as `==`, so 1 == 1.0 hold inside collections and for `equal` and `in` too
+	if isNumber(left) && isNumber(right) && left.Type() != right.Type() {
+		return toFloat(left) == toFloat(right), nil
+	}
+
+	if left.Type() != right.Type() {
+		return false, nil
+	}
+
+	switch left := left.(type) {
+	case *object.Integer:
+		return left.Value == right.(*object.Integer).Value, nil
+
+	case *object.String:
+		return left.Value == right.(*object.String).Value, nil
+
+	case *object.Float:
+		return left.Value == right.(*object.Float).Value, nil
+
+	case *object.Array:
+		// Same length and pairwise equal elements, nested arrays are compared the same way
+		right := right.(*object.Array)
+
+		if comparing(seen, left, right) {
+			return true, nil
+		}
+
+		if len(left.Elements) != len(right.Elements) {
+			return false, nil
+		}
+
+		for i, elem := range left.Elements {
+			if equal, errObj := valuesEqualSeen(elem, right.Elements[i], seen); errObj != nil || !equal {
+				return false, errObj
+			}
+		}
+
+		return true, nil
+
+	case *object.Hash:
+		right := right.(*object.Hash)
+
+		if eq := eqFunction(left, right); eq != nil {
+			result := applyFunction(eq, []object.Object{left, right})
+
+			if errObj, ok := result.(*object.Error); ok {
+				return false, errObj
+			}
+
+			return isTruthy(result), nil
+		}
+
+		if comparing(seen, left, right) {
+			return true, nil
+		}
+
+		// Same set of keys with equal values, the order of the pairs doesnt matter
+		if len(left.Pairs) != len(right.Pairs) {
+			return false, nil
+		}
+
+		for hashKey, pair := range left.Pairs {
+			other, ok := right.Pairs[hashKey]
+
+			if !ok {
+				return false, nil
+			}
+
+			if equal, errObj := valuesEqualSeen(pair.Value, other.Value, seen); errObj != nil || !equal {
+				return false, errObj
+			}
+		}
+
+		return true, nil
+
+	default:
+		return left == right, nil
+	}
+}
+
+// comparing report whether two collections need no further comparison, either because they are
+// the same object or because the pair is already being compared further up. Otherwise the pair is
+// marked so a cycle leading back to it stop there
+func comparing(seen map[[2]object.Object]bool, left object.Object, right object.Object) bool {
+	pair := [2]object.Object{left, right}
+
+	if left == right || seen[pair] {
+		return true
+	}
+
+	seen[pair] = true
+	return false
+}
+
+// eqFunction return the `__eq` function of the left hash, or of the right one, or nil when neither has one
+func eqFunction(left *object.Hash, right *object.Hash) object.Object {
+	key := (&object.String{Value: EQ_FUNCTION_KEY}).HashKey()
+
+	for _, hash := range []*object.Hash{left, right} {
+		if pair, ok := hash.Pairs[key]; ok {
+			switch pair.Value.(type) {
+			case *object.Function, *object.Builtin:
+				return pair.Value
+			}
+		}
+	}
+
+	return nil
+}
+
+// evalConditionalAssignment handle `x ||= y` and `x &&= y`. The right side is only
+// evaluated and assigned when `x` is falsy for `||=` or truthy for `&&=`
+func evalConditionalAssignment(node *ast.AssignmentExpression, env *object.Environment) object.Object {
+	current, ok := env.Get(node.Name.Value)
+
+	if !ok {
+		return newError("identifier not found `%s`", node.Name.Value)
+	}
+
+	if isTruthy(current) == (node.Token.Literal == "||=") {
+		return current
+	}
+
+	val := Eval(node.Value, env)
+
+	if isError(val) {
+		return val
+	}
+
+	env.Assign(node.Name.Value, val)
+
+	return val
+}
+
+// PIPE_PLACEHOLDER mark the argument position of the piped value. eg: x |> f(1, _)
+const PIPE_PLACEHOLDER = "_"
+
+func evalPipeExpression(node *ast.PipeExpression, env *object.Environment) object.Object {
+	val := Eval(node.Left, env)
+
+	if isError(val) {
+		return val
+	}
+
+	call, ok := node.Right.(*ast.CallExpression)
+
+	// x |> f => f(x)
+	if !ok {
+		fn := Eval(node.Right, env)
+
+		if isError(fn) {
+			return fn
+		}
+
+		return applyFunction(fn, []object.Object{val})
+	}
+
+	fn := Eval(call.Function, env)
+
+	if isError(fn) {
+		return fn
+	}
+
+	// x |> f(_, 2) => f(x, 2) and x |> f(2) => f(x, 2)
+	args := []object.Object{}
+	placed := false
+
+	for _, arg := range call.Arguments {
+		if ident, ok := arg.(*ast.Identifier); ok && ident.Value == PIPE_PLACEHOLDER {
+			args = append(args, val)
+			placed = true
+			continue
+		}
+
+		evaluated := Eval(arg, env)
+
+		if isError(evaluated) {
+			return evaluated
+		}
+
+		args = append(args, evaluated)
+	}
+
+	if !placed {
+		args = append([]object.Object{val}, args...)
+	}
+
+	return applyFunction(fn, args)
+}
@@ -4,7 +4,13 @@ import (
 	"Monkey/lexer"
 	"Monkey/object"
 	"Monkey/parser"
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -27,6 +33,32 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"3 * 3 * 3 + 10", 37},
 		{"3 * (3 * 3) + 10", 37},
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"10 % 3", 1},
+		{"9 % 3", 0},
+		{"-7 % 3", -1},
+		{"1 + 10 % 4", 3},
+		{"(1 + 10) % 4", 3},
+		{"2 * 5 % 3", 1},
+		{"2 ** 10", 1024},
+		{"2 ** 0", 1},
+		{"2 ** 3 ** 2", 512},
+		{"2 * 3 ** 2", 18},
+		{"-2 ** 2", 4},
+		{"3 ** 5", 243},
+		{"7 ** 1", 7},
+		{"-3 ** 3", -27},
+		{"2 ** 62", 4611686018427387904},
+		{"-2 ** 63", -9223372036854775808},
+		{"1 ** 9223372036854775807", 1},
+		{"-1 ** 9223372036854775807", -1},
+		{"0 ** 9223372036854775807", 0},
+		{"1 << 4", 16},
+		{"256 >> 4", 16},
+		{"256 >>> 4", 16},
+		{"-16 >> 2", -4},
+		{"-16 >>> 2", 4611686018427387900},
+		{"-1 >>> 63", 1},
+		{"1 + 1 << 2", 8},
 	}
 
 	for _, test := range tests {
@@ -215,27 +247,27 @@ func TestErrorHandling(t *testing.T) {
 	}{
 		{
 			"5 + true;",
-			"type mismatch: INTEGER + BOOLEAN",
+			"type error: cannot use BOOLEAN in arithmetic",
 		},
 		{
 			"5 + true; 5;",
-			"type mismatch: INTEGER + BOOLEAN",
+			"type error: cannot use BOOLEAN in arithmetic",
 		},
 		{
 			"-true",
-			"unknown operator: -BOOLEAN",
+			"type error: cannot use BOOLEAN in arithmetic",
 		},
 		{
 			"true + false;",
-			"unknown operator: BOOLEAN + BOOLEAN",
+			"type error: cannot use BOOLEAN in arithmetic",
 		},
 		{
 			"5; true + false; 5",
-			"unknown operator: BOOLEAN + BOOLEAN",
+			"type error: cannot use BOOLEAN in arithmetic",
 		},
 		{
 			"if (10 > 1) { true + false; }",
-			"unknown operator: BOOLEAN + BOOLEAN",
+			"type error: cannot use BOOLEAN in arithmetic",
 		},
 		{
 			`
@@ -245,7 +277,7 @@ func TestErrorHandling(t *testing.T) {
 				}
 				return 1;
 			}
-			`, "unknown operator: BOOLEAN + BOOLEAN",
+			`, "type error: cannot use BOOLEAN in arithmetic",
 		},
 		{
 			"foobar;",
@@ -259,6 +291,26 @@ func TestErrorHandling(t *testing.T) {
 			`{"name":"Monkey"}[fn(x){x}];`,
 			"unusable as hash key: FUNCTION",
 		},
+		{
+			"10 % 0",
+			"modulo by zero in (10 % 0)",
+		},
+		{
+			"2 ** -1",
+			"negative exponent: 2 ** -1",
+		},
+		{
+			"1 >> -1",
+			"negative shift count: 1 >> -1",
+		},
+		{
+			"2 ** 63",
+			"integer overflow: 2 ** 63",
+		},
+		{
+			"10 ** 9223372036854775807",
+			"integer overflow: 10 ** 9223372036854775807",
+		},
 	}
 
 	for _, test := range tests {
@@ -362,6 +414,49 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`rest([])`, nil},
 		{`push([], 1)`, []int{1}},
 		{`push(1, 1)`, "first argument to `push` must be an ARRAY, got=INTEGER"},
+		{`scan([1, 2, 3], 0, fn(acc, x) { acc + x })`, []int{0, 1, 3, 6}},
+		{`scan([], 5, fn(acc, x) { acc + x })`, []int{5}},
+		{`scan(1, 0, fn(acc, x) { acc + x })`, "first argument to `scan` must be an ARRAY or RANGE, got=INTEGER"},
+		{`generate(4, fn(i) { i * i })`, []int{0, 1, 4, 9}},
+		{`generate(0, fn(i) { i })`, []int{}},
+		{`generate(-1, fn(i) { i })`, "first argument to `generate` must not be negative, got=-1"},
+		{`generate(3, fn(i) { i + true })`, "type error: cannot use BOOLEAN in arithmetic"},
+		{`generate(9223372036854775807, fn(i) { i + true })`, "type error: cannot use BOOLEAN in arithmetic"},
+		{`filter([1, 2, 3, 4], fn(x) { x % 2 == 0 })`, []int{2, 4}},
+		{`filter([], fn(x) { true })`, []int{}},
+		{`filter([1, 2], fn(x) { x + true })`, "type error: cannot use BOOLEAN in arithmetic"},
+		{`filter(1, fn(x) { true })`, "first argument to `filter` must be an ARRAY or RANGE, got=INTEGER"},
+		{`filter([1])`, "wrong number of arguments. got=1, want=2"},
+		{`reduce([1, 2, 3, 4], 0, fn(acc, x) { acc + x })`, 10},
+		{`reduce([], 7, fn(acc, x) { acc + x })`, 7},
+		{`reduce([1, 2], 0, fn(acc, x) { acc + true })`, "type error: cannot use BOOLEAN in arithmetic"},
+		{`reduce(1, 0, fn(acc, x) { acc })`, "first argument to `reduce` must be an ARRAY or RANGE, got=INTEGER"},
+		{`range(5)`, []int{0, 1, 2, 3, 4}},
+		{`range(2, 5)`, []int{2, 3, 4}},
+		{`range(0, 10, 3)`, []int{0, 3, 6, 9}},
+		{`range(5, 0, -2)`, []int{5, 3, 1}},
+		{`range(0)`, []int{}},
+		{`range(5, 2)`, []int{}},
+		{`range(-3)`, []int{}},
+		{`range(1, 5, 0)`, "step of `range` must not be zero"},
+		{`range("5")`, "arguments to `range` must be INTEGER, got=STRING"},
+		{`range()`, "wrong number of arguments. got=0, want=1 to 3"},
+		{`range(0, 9223372036854775807, 2)`, "`range` would build 4611686018427387904 elements, more than the limit of 10000000. use `xrange` instead"},
+		{`digits(123)`, []int{1, 2, 3}},
+		{`digits(0)`, []int{0}},
+		{`digits(7)`, []int{7}},
+		{`digits(1005)`, []int{1, 0, 0, 5}},
+		{`digits(10, 2)`, []int{1, 0, 1, 0}},
+		{`digits(255, 16)`, []int{15, 15}},
+		{`digits(-12)`, "first argument to `digits` must not be negative, got=-12"},
+		{`digits(12, 1)`, "base for `digits` must be at least 2, got=1"},
+		{`byte_len("héllo")`, 6},
+		{`rune_len("héllo")`, 5},
+		{`byte_len("日本")`, 6},
+		{`rune_len("日本")`, 2},
+		{`byte_len("")`, 0},
+		{`byte_len(1)`, "argument to `byte_len` must be a STRING, got=INTEGER"},
+		{`rune_len([])`, "argument to `rune_len` must be a STRING, got=ARRAY"},
 	}
 
 	for _, test := range tests {
@@ -472,6 +567,14 @@ func TestArrayIndexExpressions(t *testing.T) {
 		},
 		{
 			"[1, 2, 3][-1]",
+			3,
+		},
+		{
+			"[1, 2, 3][-3]",
+			1,
+		},
+		{
+			"[1, 2, 3][-4]",
 			nil,
 		},
 	}
@@ -579,54 +682,2434 @@ func TestHashIndexExpressions(t *testing.T) {
 	}
 }
 
-// --------------------------------
-// Private function
-// --------------------------------
-func testEval(input string) object.Object {
-	l := lexer.New(input)
-	p := parser.New(l)
-	program := p.ParseProgram()
-	env := object.NewEnvironment()
+func TestArgsBuiltin(t *testing.T) {
+	ScriptArgs = []string{"foo", "bar"}
+	defer func() { ScriptArgs = []string{} }()
 
-	return Eval(program, env)
+	evaluated := testEval(`args()`)
+
+	arr, ok := evaluated.(*object.Array)
+
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(arr.Elements) != 2 {
+		t.Fatalf("wrong number of elements. want=%d, got=%d", 2, len(arr.Elements))
+	}
+
+	for i, expected := range []string{"foo", "bar"} {
+		str, ok := arr.Elements[i].(*object.String)
+
+		if !ok {
+			t.Fatalf("element is not String. got=%T (%+v)", arr.Elements[i], arr.Elements[i])
+		}
+
+		if str.Value != expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", expected, str.Value)
+		}
+	}
 }
 
-func testIntegerObject(t *testing.T, _obj object.Object, expected int64) bool {
-	obj, ok := _obj.(*object.Integer)
+func TestEnvBuiltin(t *testing.T) {
+	t.Setenv("MONKEY_TEST_ENV", "banana")
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`env("MONKEY_TEST_ENV")`, "banana"},
+		{`env("MONKEY_TEST_UNSET")`, nil},
+		{`env("MONKEY_TEST_UNSET", "default")`, "default"},
+		{`env("MONKEY_TEST_ENV", "default")`, "banana"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		expected, ok := test.expected.(string)
+
+		if !ok {
+			testNullObject(t, evaluated)
+			continue
+		}
+
+		testStringObject(t, evaluated, expected)
+	}
+}
+
+func TestSleepBuiltin(t *testing.T) {
+	var slept time.Duration
+
+	sleep = func(d time.Duration) { slept = d }
+	defer func() { sleep = time.Sleep }()
+
+	testNullObject(t, testEval(`sleep(5)`))
+
+	if slept != 5*time.Millisecond {
+		t.Errorf("wrong sleep duration. want=%s, got=%s", 5*time.Millisecond, slept)
+	}
+
+	evaluated := testEval(`sleep(-1)`)
+	errObj, ok := evaluated.(*object.Error)
 
 	if !ok {
-		t.Errorf("object is not Integer. got=%T (%+v)", _obj, _obj)
-		return false
+		t.Fatalf("object is not Error. got=%T (%+v)", evaluated, evaluated)
 	}
 
-	if obj.Value != expected {
-		t.Errorf("object has wrong value. got=%d, want=%d", obj.Value, expected)
-		return false
+	expected := "argument to `sleep` must not be negative, got=-1"
+
+	if errObj.Message != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+	}
+}
+
+func TestDestructuringAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let a = 1; let b = 2; [a, b] = [b, a]; a", 2},
+		{"let a = 1; let b = 2; [a, b] = [b, a]; b", 1},
+		{"let a = 1; let b = 2; [a, b] = [1];", "destructuring mismatch. got=1 values, want=2"},
+		{"let a = 1; [a] = 5;", "cannot destructure INTEGER"},
+		{"[z] = [1];", "identifier not found `z`"},
 	}
 
-	return true
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		switch expected := test.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
 }
 
-func testBooleanObject(t *testing.T, _obj object.Object, expected bool) bool {
-	obj, ok := _obj.(*object.Boolean)
+func TestImplicitReturn(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let f = fn(x) { x + 1 }; f(1);", 2},
+		{"let f = fn(x) { return x + 1; }; f(1);", 2},
+		{"let f = fn(x) { if (x > 1) { return 10; } x }; f(5);", 10},
+		{"let f = fn(x) { if (x > 1) { return 10; } x }; f(1);", 1},
+		{"let f = fn(x) { let y = x + 1; }; f(1);", nil},
+		{"let f = fn() { }; f();", nil},
+		{"let a = 1; let f = fn() { a = 2 }; f();", 2},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		integer, ok := test.expected.(int)
+
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestPartitionBuiltin(t *testing.T) {
+	input := `partition([1, 2, 3, 4, 5], fn(x) { x / 2 * 2 == x })`
+
+	evaluated := testEval(input)
+
+	arr, ok := evaluated.(*object.Array)
 
 	if !ok {
-		t.Errorf("object is not Boolean. got=%T (%+v)", _obj, _obj)
-		return false
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
 	}
 
-	if obj.Value != expected {
-		t.Errorf("object has wrong value. got=%T, want=%t", obj.Value, expected)
-		return false
+	if len(arr.Elements) != 2 {
+		t.Fatalf("wrong number of elements. want=%d, got=%d", 2, len(arr.Elements))
 	}
 
-	return true
+	expected := [][]int64{{2, 4}, {1, 3, 5}}
+
+	for i, group := range expected {
+		groupArr, ok := arr.Elements[i].(*object.Array)
+
+		if !ok {
+			t.Fatalf("group is not Array. got=%T (%+v)", arr.Elements[i], arr.Elements[i])
+		}
+
+		if len(groupArr.Elements) != len(group) {
+			t.Fatalf("wrong number of elements. want=%d, got=%d", len(group), len(groupArr.Elements))
+		}
 
+		for j, val := range group {
+			testIntegerObject(t, groupArr.Elements[j], val)
+		}
+	}
 }
 
-func testNullObject(t *testing.T, obj object.Object) bool {
-	if obj != NULL {
-		t.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
+func TestSequenceExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"(1, 2, 3)", 3},
+		{"let a = 0; let b = 0; (a = 1, b = 2, a + b)", 3},
+		{"let a = 0; let b = (a = 5, a * 2); a + b", 15},
+		{"let add = fn(x, y) { x + y }; add((1, 2), 3)", 5},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		testIntegerObject(t, evaluated, test.expected)
+	}
+}
+
+func TestForInStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let sum = 0; for (x in [1, 2, 3]) { sum = sum + x; } sum", 6},
+		{"let sum = 0; for (i, x in [10, 20, 30]) { sum = sum + i; } sum", 3},
+		{`let sum = 0; for (k, v in {"a": 1, "b": 2}) { sum = sum + v; } sum`, 3},
+		{`let sum = 0; for (k in {1: "a", 2: "b"}) { sum = sum + k; } sum`, 3},
+		{"let sum = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { break; } sum = sum + x; } sum", 3},
+		{"let sum = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { continue; } sum = sum + x; } sum", 7},
+		{"let f = fn() { for (x in [1, 2, 3]) { if (x == 2) { return x; } } 0 }; f()", 2},
+		{"for (x in 5) { x }", "cannot iterate over INTEGER"},
+		{"break;", "`break` outside of loop"},
+		{"let f = fn() { continue; }; f()", "`continue` outside of loop"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		switch expected := test.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestAssignmentUpdatesDefiningScope(t *testing.T) {
+	input := `
+	let count = 0;
+	let inc = fn() { count = count + 1; };
+	inc();
+	inc();
+	count;
+	`
+
+	testIntegerObject(t, testEval(input), 2)
+}
+
+func TestBuildBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`build(3, fn(i) { "item" }, ", ")`, "item, item, item"},
+		{`let names = ["a", "b", "c"]; build(3, fn(i) { names[i] }, ",")`, "a,b,c"},
+		{`build(0, fn(i) { "x" }, ",")`, ""},
+		{`build(2, fn(i) { i }, ",")`, object.Error{Message: "callback to `build` must return a STRING, got=INTEGER"}},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		switch expected := test.expected.(type) {
+		case string:
+			testStringObject(t, evaluated, expected)
+
+		case object.Error:
+			errObj, ok := evaluated.(*object.Error)
+
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if errObj.Message != expected.Message {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected.Message, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestStrictIndexing(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"[1, 2, 3][3]", "index out of range: 3"},
+		{"[1, 2, 3][-4]", "index out of range: -4"},
+		{`{"foo": 5}["bar"]`, "key not found: bar"},
+		{`"foo"[3]`, "index out of range: 3"},
+	}
+
+	// Default mode keep returning `NULL`
+	for _, test := range tests {
+		testNullObject(t, testEval(test.input))
+	}
+
+	StrictIndexing = true
+	defer func() { StrictIndexing = false }()
+
+	for _, test := range tests {
+		testErrorObject(t, testEval(test.input), test.expectedError)
+	}
+
+	testIntegerObject(t, testEval("[1, 2, 3][2]"), 3)
+	testIntegerObject(t, testEval(`{"foo": 5}["foo"]`), 5)
+}
+
+func TestFlattenHashBuiltin(t *testing.T) {
+	input := `flatten_hash({"a": {"b": 1, "c": {"d": 2}}, "e": 3, "f": [4, 5]})`
+
+	evaluated := testEval(input)
+
+	hash, ok := evaluated.(*object.Hash)
+
+	if !ok {
+		t.Fatalf("object is not Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := map[string]int64{
+		"a.b":   1,
+		"a.c.d": 2,
+		"e":     3,
+		"f[0]":  4,
+		"f[1]":  5,
+	}
+
+	if len(hash.Pairs) != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. want=%d, got=%d", len(expected), len(hash.Pairs))
+	}
+
+	for key, val := range expected {
+		pair, ok := hash.Pairs[(&object.String{Value: key}).HashKey()]
+
+		if !ok {
+			t.Errorf("no pair for key %q", key)
+			continue
+		}
+
+		testIntegerObject(t, pair.Value, val)
+	}
+
+	testErrorObject(t, testEval(`flatten_hash([1])`), "argument to `flatten_hash` must be a HASH, got=ARRAY")
+}
+
+func TestMembershipOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"2 in [1, 2, 3]", true},
+		{"4 in [1, 2, 3]", false},
+		{"2 not in [1, 2, 3]", false},
+		{"4 not in [1, 2, 3]", true},
+		{`"a" in {"a": 1}`, true},
+		{`"b" not in {"a": 1}`, true},
+		{`"ell" in "hello"`, true},
+		{`"xyz" not in "hello"`, true},
+		{`"1" in [1, 2]`, false},
+		{"2 in []", false},
+		{`"b" in {"a": 1}`, false},
+		{`1 in {1: "a", true: "b"}`, true},
+		{`"xyz" in "hello"`, false},
+		{`"" in "hello"`, true},
+		{"7 in xrange(1, 10, 3)", true},
+		{"8 in xrange(1, 10, 3)", false},
+		{"10 in xrange(1, 10, 3)", false},
+		{"0 in xrange(1, 10, 3)", false},
+		{"-2 in xrange(1, 10, 3)", false},
+		{"4 in xrange(10, 0, -3)", true},
+		{"1 not in xrange(10, 0, -3)", false},
+		{"999999999 in xrange(1000000000)", true},
+		{"9223372036854775806 in xrange(-10, 9223372036854775807)", true},
+		{"9223372036854775806 in xrange(-10, 9223372036854775807, 2)", true},
+		{"9223372036854775805 in xrange(-10, 9223372036854775807, 2)", false},
+		{"-10 in xrange(9223372036854775807, -11, -9223372036854775807)", false},
+		{"-9223372036854775807 in xrange(9223372036854775807, -9223372036854775807, -9223372036854775807)", false},
+		{"0 in xrange(9223372036854775807, -9223372036854775807, -9223372036854775807)", true},
+		{`"1" in xrange(5)`, false},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		testBooleanObject(t, evaluated, test.expected)
+	}
+
+	testErrorObject(t, testEval("1 not in 5"), "unknown operator: INTEGER in INTEGER")
+}
+
+func TestDeepMergeBuiltin(t *testing.T) {
+	input := `
+	let a = {"x": 1, "db": {"host": "localhost", "opts": {"ssl": false, "pool": 5}}, "tags": [1, 2]};
+	let b = {"y": 2, "db": {"opts": {"ssl": true}}, "tags": [3]};
+	let m = deep_merge(a, b);
+	[m["x"], m["y"], m["db"]["host"], m["db"]["opts"]["ssl"], m["db"]["opts"]["pool"], len(m["tags"]), a["db"]["opts"]["ssl"]]
+	`
+
+	evaluated := testEval(input)
+
+	arr, ok := evaluated.(*object.Array)
+
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	testIntegerObject(t, arr.Elements[0], 1)
+	testIntegerObject(t, arr.Elements[1], 2)
+	testStringObject(t, arr.Elements[2], "localhost")
+	testBooleanObject(t, arr.Elements[3], true)
+	testIntegerObject(t, arr.Elements[4], 5)
+	testIntegerObject(t, arr.Elements[5], 1)     // arrays are replaced, not concatenated
+	testBooleanObject(t, arr.Elements[6], false) // inputs are left untouched
+
+	testErrorObject(t, testEval(`deep_merge({}, 1)`), "second argument to `deep_merge` must be a HASH, got=INTEGER")
+}
+
+func TestArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let inc = fn(x) => x + 1; inc(1);", 2},
+		{"let inc = fn(x) { x + 1 }; inc(1);", 2},
+		{"let add = fn(x, y) => x + y; add(2, 3);", 5},
+		{"let adder = fn(x) => fn(y) => x + y; adder(2)(3);", 5},
+		{"scan([1, 2], 0, fn(acc, x) => acc + x)[2]", 3},
+		{"(fn() => 7 * 6)()", 42},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		testIntegerObject(t, evaluated, test.expected)
+	}
+}
+
+func TestMaxDepthBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"max_depth()", 0},
+		{"let f = fn(x) { x }; f(1); max_depth()", 1},
+		{"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(4); max_depth()", 5},
+		{"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(4); f(1); max_depth()", 5},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		testIntegerObject(t, evaluated, test.expected)
+	}
+}
+
+func TestMaxDepthPerInterpreter(t *testing.T) {
+	inputs := []struct {
+		input    string
+		expected int64
+	}{
+		{"let f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(20); max_depth()", 21},
+		{"let g = fn(x) { x }; g(1); max_depth()", 1},
+	}
+
+	results := make([]object.Object, len(inputs))
+	done := make(chan int)
+
+	for i, test := range inputs {
+		go func(i int, input string) {
+			results[i] = testEval(input)
+			done <- i
+		}(i, test.input)
+	}
+
+	for range inputs {
+		<-done
+	}
+
+	for i, test := range inputs {
+		testIntegerObject(t, results[i], test.expected)
+	}
+
+	testErrorObject(t, testEval(`map([1], max_depth)`), "`max_depth` must be called directly")
+}
+
+func TestTemplateBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`template("Hello {name}, you are {age}", {"name": "Monkey", "age": 5})`, "Hello Monkey, you are 5"},
+		{`template("{name} {unknown}", {"name": "Monkey"})`, "Monkey {unknown}"},
+		{`template("no placeholders", {})`, "no placeholders"},
+		{`template("unclosed {name", {"name": "Monkey"})`, "unclosed {name"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		testStringObject(t, evaluated, test.expected)
+	}
+
+	testErrorObject(t, testEval(`template("x", 1)`), "second argument to `template` must be a HASH, got=INTEGER")
+}
+
+func TestStatsBuiltin(t *testing.T) {
+	evaluated := testEval(`let s = stats([4, 1, 3, 2]); [s["min"], s["max"], s["sum"], s["count"], s["mean"]]`)
+
+	arr, ok := evaluated.(*object.Array)
+
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	testIntegerObject(t, arr.Elements[0], 1)
+	testIntegerObject(t, arr.Elements[1], 4)
+	testIntegerObject(t, arr.Elements[2], 10)
+	testIntegerObject(t, arr.Elements[3], 4)
+
+	mean, ok := arr.Elements[4].(*object.Float)
+
+	if !ok {
+		t.Fatalf("mean is not Float. got=%T (%+v)", arr.Elements[4], arr.Elements[4])
+	}
+
+	if mean.Value != 2.5 {
+		t.Errorf("mean has wrong value. want=%f, got=%f", 2.5, mean.Value)
+	}
+
+	testErrorObject(t, testEval(`stats([])`), "argument to `stats` must not be empty")
+	testErrorObject(t, testEval(`stats([1, "a"])`), "element of `stats` must be an INTEGER or FLOAT, got=STRING")
+
+	floats := testEval(`let s = stats([1.5, 2.5, 1]); [s["min"], s["max"], s["sum"], s["count"], s["mean"]]`)
+
+	if floats.Inspect() != "[1, 2.5, 5, 3, 1.6666666666666667]" {
+		t.Errorf("wrong stats for floats. got=%s", floats.Inspect())
+	}
+
+	if _, ok := testEval(`stats([1.5, 2.5])["sum"]`).(*object.Float); !ok {
+		t.Errorf("sum of floats is not Float")
+	}
+}
+
+func TestPipeExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let double = fn(x) { x * 2 }; 5 |> double", 10},
+		{"let sub = fn(x, y) { x - y }; 10 |> sub(3)", 7},
+		{"let sub = fn(x, y) { x - y }; 10 |> sub(_, 3)", 7},
+		{"let sub = fn(x, y) { x - y }; 3 |> sub(10, _)", 7},
+		{"let double = fn(x) { x * 2 }; let sub = fn(x, y) { x - y }; 1 |> double |> sub(10, _)", 8},
+		{"[1, 2, 3] |> len", 3},
+		{"5 |> 1", "not a function: INTEGER"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		switch expected := test.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestGetPathBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`let cfg = {"db": {"port": 3306}}; get_path(cfg, ["db", "port"], 5432)`, 3306},
+		{`let cfg = {"db": {"port": 3306}}; get_path(cfg, ["cache", "port"], 5432)`, 5432},
+		{`let cfg = {"db": {"host": "x"}}; get_path(cfg, ["db", "port"], 5432)`, 5432},
+		{`let cfg = {"hosts": [{"port": 1}, {"port": 2}]}; get_path(cfg, ["hosts", 1, "port"], 0)`, 2},
+		{`let cfg = {"hosts": [{"port": 1}]}; get_path(cfg, ["hosts", 5, "port"], 0)`, 0},
+		{`get_path({"a": 1}, ["a", "b"], 7)`, 7},
+		{`get_path(9, [], 7)`, 9},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		testIntegerObject(t, evaluated, test.expected)
+	}
+}
+
+func TestChainedAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let a = 1; a = 5", 5},
+		{"let a = 1; let b = 2; let c = 3; a = b = c = 5; a + b + c", 15},
+		{"let a = 1; let b = 2; a = b = 7; b", 7},
+		{"let a = 1; let b = 2; [a, b] = [b, a]; a", 2},
+		{"let a = 1; a = z = 5", "identifier not found `z`"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		switch expected := test.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestCombinatoricBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`combinations([1, 2, 3], 2)`, "[[1, 2], [1, 3], [2, 3]]"},
+		{`combinations([1, 2, 3], 0)`, "[[]]"},
+		{`combinations([1, 2, 3], 3)`, "[[1, 2, 3]]"},
+		{`combinations([1, 2], 3)`, "[]"},
+		{`permutations([1, 2, 3])`, "[[1, 2, 3], [1, 3, 2], [2, 1, 3], [2, 3, 1], [3, 2, 1], [3, 1, 2]]"},
+		{`permutations([])`, "[[]]"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`permutations([1, 2, 3, 4, 5, 6, 7, 8, 9, 10])`), "too many permutations, limit is 100000")
+	testErrorObject(t, testEval(`combinations(digits(4611686018427387903, 2), 31)`), "too many combinations, limit is 100000")
+}
+
+func TestWhileExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; while (i < 5) { i = i + 1 }; i", 5},
+		{"let i = 0; while (i < 5) { i = i + 1 }", 5},
+		{"while (false) { 1 }", nil},
+		{"let i = 0; while (i < 100000) { i = i + 1 }; i", 100000},
+		{"let i = 0; while (true) { i = i + 1; if (i == 3) { break; } }; i", 3},
+		{"let i = 0; let sum = 0; while (i < 5) { i = i + 1; if (i == 2) { continue; } sum = sum + i; }; sum", 13},
+		{"let f = fn() { let i = 0; while (true) { i = i + 1; if (i == 4) { return i; } } }; f()", 4},
+		{"let i = 0; while (i < 5) { i = i + true }", "type error: cannot use BOOLEAN in arithmetic"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		switch expected := test.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestForExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let sum = 0; for (let i = 1; i < 11; i = i + 1) { sum = sum + i; }; sum", 55},
+		{"let sum = 0; let i = 1; for (; i < 11; i = i + 1) { sum = sum + i; }; i", 11},
+		{"for (let i = 0; i < 3; i = i + 1) { i * 10 }", 20},
+		{"for (let i = 0; i < 0; i = i + 1) { i }", nil},
+		{"for (let i = 0; i < 3; i = i + 1) { }; i", "identifier not found: i"},
+		{"let sum = 0; for (let i = 0; ; i = i + 1) { if (i == 5) { break; } sum = sum + i; }; sum", 10},
+		{"let sum = 0; for (let i = 0; i < 5; i = i + 1) { if (i == 2) { continue; } sum = sum + i; }; sum", 8},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		switch expected := test.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestCondBuiltin(t *testing.T) {
+	classify := `
+	let classify = fn(x) {
+		cond([
+			[fn() { x < 0 }, fn() { "negative" }],
+			[fn() { x == 0 }, fn() { "zero" }],
+			[fn() { x > 0 }, fn() { "positive" }]
+		])
+	};
+	`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{classify + `classify(-5)`, "negative"},
+		{classify + `classify(0)`, "zero"},
+		{classify + `classify(7)`, "positive"},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	testNullObject(t, testEval(`cond([[fn() { false }, fn() { 1 }]])`))
+	testIntegerObject(t, testEval(`cond([[fn() { true }, fn() { 1 }], [fn() { true }, fn() { 2 }]])`), 1)
+	testErrorObject(t, testEval(`cond([1])`), "`cond` expects [condition, value] pairs, got=1")
+}
+
+func TestBreakAndContinue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{
+			`let i = 0;
+			while (true) {
+				if (i == 7) { break; }
+				i = i + 1;
+			}
+			i`,
+			7,
+		},
+		{
+			`let i = 0;
+			let odds = [];
+			while (i < 10) {
+				i = i + 1;
+				if (i % 2 == 0) { continue; }
+				odds = push(odds, i);
+			}
+			len(odds)`,
+			5,
+		},
+		{
+			`let found = 0;
+			for (x in [1, 2, 3]) {
+				for (y in [10, 20, 30]) {
+					if (y == 20) { break; }
+					found = found + x * y;
+				}
+			}
+			found`,
+			60,
+		},
+		{"continue;", "`continue` outside of loop"},
+		{"while (true) { let f = fn() { break; }; f(); }", "`break` outside of loop"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		switch expected := test.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestUrlEncodeDecodeBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`url_encode("hello world")`, "hello+world"},
+		{`url_encode("a&b=c/d?e")`, "a%26b%3Dc%2Fd%3Fe"},
+		{`url_decode("hello+world%21")`, "hello world!"},
+		{`url_decode(url_encode("name=Monkey & co/100%"))`, "name=Monkey & co/100%"},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`url_decode("%zz")`), `could not decode "%zz": invalid URL escape "%zz"`)
+}
+
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"hello"[0]`, "h"},
+		{`"hello"[1]`, "e"},
+		{`"hello"[4]`, "o"},
+		{`let s = "hello"; s[1 + 1]`, "l"},
+		{`"hello"[5]`, nil},
+		{`"hello"[-1]`, "o"},
+		{`"hello"[-5]`, "h"},
+		{`"hello"[-6]`, nil},
+		{`""[0]`, nil},
+		{`"héllo"[0]`, "h"},
+		{`"héllo"[1]`, "\xc3"},
+		{`"héllo"[2]`, "\xa9"},
+		{`"héllo"[3]`, "l"},
+		{`"héllo"[-1]`, "o"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if expected, ok := test.expected.(string); ok {
+			testStringObject(t, evaluated, expected)
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+
+	// Indexing, slicing and `len` all count bytes
+	testBooleanObject(t, testEval(`let s = "héllo"; s[1] + s[2] == s[1:3]`), true)
+	testBooleanObject(t, testEval(`let s = "héllo"; s[1] == s[1:2]`), true)
+	testIntegerObject(t, testEval(`len("héllo")`), 6)
+}
+
+func TestBase64Builtins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`base64_encode("hello monkey")`, "aGVsbG8gbW9ua2V5"},
+		{`base64_encode("")`, ""},
+		{`base64_decode("aGVsbG8gbW9ua2V5")`, "hello monkey"},
+		{`base64_decode(base64_encode("line1\nline2 & ü"))`, "line1\nline2 & ü"},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`base64_decode("not base64!")`), `could not decode "not base64!": illegal base64 data at input byte 3`)
+	testErrorObject(t, testEval(`base64_encode(1)`), "argument to `base64_encode` must be a STRING, got=INTEGER")
+}
+
+func TestDigestBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`sha256("")`, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{`sha256("abc")`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{`md5("")`, "d41d8cd98f00b204e9800998ecf8427e"},
+		{`md5("abc")`, "900150983cd24fb0d6963f7d28e17f72"},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`sha256(1)`), "argument to `sha256` must be a STRING, got=INTEGER")
+	testErrorObject(t, testEval(`md5([])`), "argument to `md5` must be a STRING, got=ARRAY")
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3, 4, 5][1:3]", "[2, 3]"},
+		{"[1, 2, 3, 4, 5][:2]", "[1, 2]"},
+		{"[1, 2, 3, 4, 5][3:]", "[4, 5]"},
+		{"[1, 2, 3, 4, 5][:]", "[1, 2, 3, 4, 5]"},
+		{"[1, 2, 3][3:1]", "[]"},
+		{"[1, 2, 3][1:100]", "[2, 3]"},
+		{"[1, 2, 3][10:]", "[]"},
+		{"[1, 2, 3][:-10]", "[]"},
+		{`"hello world"[0:5]`, "hello"},
+		{`"hello world"[6:]`, "world"},
+		{`"hello"[4:2]`, ""},
+		{`let s = "monkey"; let i = 1; s[i:i + 3]`, "onk"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%q, got=%q", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`5[1:2]`), "slice operator not supported: INTEGER")
+	testErrorObject(t, testEval(`[1, 2][true:]`), "slice bound must be an INTEGER, got=BOOLEAN")
+}
+
+func TestReadAllBuiltin(t *testing.T) {
+	stdin = bufio.NewReader(strings.NewReader("line one\nline two\nline three"))
+	defer func() { stdin = bufio.NewReader(os.Stdin) }()
+
+	testStringObject(t, testEval(`read_all()`), "line one\nline two\nline three")
+	testStringObject(t, testEval(`read_all()`), "")
+}
+
+func TestReadLineBuiltin(t *testing.T) {
+	stdin = bufio.NewReader(strings.NewReader("ann\r\nbob\n\nlast"))
+	defer func() { stdin = bufio.NewReader(os.Stdin) }()
+
+	testStringObject(t, testEval(`read_line()`), "ann")
+	testStringObject(t, testEval(`let name = read_line(); "hi " + name`), "hi bob")
+	testStringObject(t, testEval(`read_line()`), "")
+	testStringObject(t, testEval(`read_line()`), "last")
+	testNullObject(t, testEval(`read_line()`))
+	testStringObject(t, testEval(`read_all()`), "")
+}
+
+func TestRegexBuiltins(t *testing.T) {
+	testBooleanObject(t, testEval(`matches("monkey42", "[0-9]+$")`), true)
+	testBooleanObject(t, testEval(`matches("monkey", "^[0-9]+")`), false)
+
+	words := testEval(`find_all("a1 b22 c333", "[0-9]+")`)
+	arr, ok := words.(*object.Array)
+
+	if !ok || len(arr.Elements) != 3 {
+		t.Fatalf("find_all did not return 3 matches. got=%s", words.Inspect())
+	}
+
+	for i, expected := range []string{"1", "22", "333"} {
+		testStringObject(t, arr.Elements[i], expected)
+	}
+
+	groups := testEval(`find_all("x=1, y=2", "([a-z])=([0-9])")`)
+
+	if groups.Inspect() != `[[x, 1], [y, 2]]` {
+		t.Errorf("wrong capture groups. got=%s", groups.Inspect())
+	}
+
+	empty := testEval(`find_all("abc", "[0-9]")`)
+
+	if empty.Inspect() != `[]` {
+		t.Errorf("expected no matches. got=%s", empty.Inspect())
+	}
+
+	testErrorObject(t, testEval(`matches("abc", "(")`), "invalid pattern \"(\": error parsing regexp: missing closing ): `(`")
+	testErrorObject(t, testEval(`find_all(1, "a")`), "first argument to `find_all` must be a STRING, got=INTEGER")
+	testErrorObject(t, testEval(`matches("a")`), "wrong number of arguments. got=1, want=2")
+}
+
+func TestReduceConcatenatesStrings(t *testing.T) {
+	evaluated := testEval(`reduce(["a", "b", "c"], "", fn(acc, s) { acc + s })`)
+	testStringObject(t, evaluated, "abc")
+}
+
+func TestRegexReplace(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`regex_replace("a1b22c333", "[0-9]", "#")`, "a#b##c###"},
+		{`regex_replace("John Smith", "(\\w+) (\\w+)", "$2, $1")`, "Smith, John"},
+		{`regex_replace("abc", "x", "y")`, "abc"},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`regex_replace("abc", "[", "")`), "invalid pattern \"[\": error parsing regexp: missing closing ]: `[`")
+	testErrorObject(t, testEval(`regex_replace("abc", "a", 1)`), "third argument to `regex_replace` must be a STRING, got=INTEGER")
+	testErrorObject(t, testEval(`regex_replace("abc", "a")`), "wrong number of arguments. got=2, want=3")
+}
+
+func TestSplitBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`split("a,b,c", ",")`, []string{"a", "b", "c"}},
+		{`split("abc", "")`, []string{"a", "b", "c"}},
+		{`split("abc", ",")`, []string{"abc"}},
+		{`split("a,,b", ",")`, []string{"a", "", "b"}},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		arr, ok := evaluated.(*object.Array)
+
+		if !ok {
+			t.Errorf("obj not Array. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if len(arr.Elements) != len(test.expected) {
+			t.Errorf("wrong number of elements. want=%d, got=%d", len(test.expected), len(arr.Elements))
+			continue
+		}
+
+		for i, element := range arr.Elements {
+			testStringObject(t, element, test.expected[i])
+		}
+	}
+
+	testErrorObject(t, testEval(`split(1, ",")`), "first argument to `split` must be a STRING, got=INTEGER")
+	testErrorObject(t, testEval(`split("a", 1)`), "second argument to `split` must be a STRING, got=INTEGER")
+}
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`format_number(0)`, "0"},
+		{`format_number(999)`, "999"},
+		{`format_number(1000)`, "1,000"},
+		{`format_number(1234567)`, "1,234,567"},
+		{`format_number(-1234567)`, "-1,234,567"},
+		{`format_number(-100)`, "-100"},
+		{`format_number(1234567, ".")`, "1.234.567"},
+		{`format_number(stats([1000, 2001])["mean"])`, "1,500.5"},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`format_number("1")`), "first argument to `format_number` must be an INTEGER or FLOAT, got=STRING")
+	testErrorObject(t, testEval(`format_number(1, 2)`), "second argument to `format_number` must be a STRING, got=INTEGER")
+	testErrorObject(t, testEval(`format_number()`), "wrong number of arguments. got=0, want=1 or 2")
+}
+
+func TestJoinBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`join(["a", "b", "c"], "-")`, "a-b-c"},
+		{`join(["only"], ", ")`, "only"},
+		{`join([], ",")`, ""},
+		{`join(split("a,b,c", ","), "")`, "abc"},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`join(["a", 1], ",")`), "elements of `join` must be STRING, got=INTEGER at index 1")
+	testErrorObject(t, testEval(`join("a", ",")`), "first argument to `join` must be an ARRAY, got=STRING")
+	testErrorObject(t, testEval(`join(["a"], 1)`), "second argument to `join` must be a STRING, got=INTEGER")
+}
+
+func TestIsSubset(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`is_subset({"a": 1}, {"a": 1, "b": 2})`, true},
+		{`is_subset({}, {"a": 1})`, true},
+		{`is_subset({"a": 1, "b": 2}, {"a": 1, "b": 2})`, true},
+		{`is_subset({"a": 1, "c": 3}, {"a": 1, "b": 2})`, false},
+		{`is_subset({"a": 2}, {"a": 1, "b": 2})`, false},
+		{`is_subset({"a": "1"}, {"a": 1})`, false},
+	}
+
+	for _, test := range tests {
+		testBooleanObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`is_subset([], {})`), "first argument to `is_subset` must be a HASH, got=ARRAY")
+	testErrorObject(t, testEval(`is_subset({}, 1)`), "second argument to `is_subset` must be a HASH, got=INTEGER")
+}
+
+func TestTypeBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`type(5)`, "INTEGER"},
+		{`type("x")`, "STRING"},
+		{`type([1])`, "ARRAY"},
+		{`type({})`, "HASH"},
+		{`type(true)`, "BOOLEAN"},
+		{`type(fn(){})`, "FUNCTION"},
+		{`type(len)`, "BUILTIN"},
+		{`type(if (false) { 1 })`, "NULL"},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`type(1, 2)`), "wrong number of arguments. got=2, want=1")
+}
+
+func TestInvertBuiltin(t *testing.T) {
+	evaluated := testEval(`let h = invert({"one": 1, "two": 2}); [h[1], h[2]]`)
+
+	if evaluated.Inspect() != "[one, two]" {
+		t.Errorf("wrong inverted values. got=%s", evaluated.Inspect())
+	}
+
+	testStringObject(t, testEval(`invert({"a": 1, "b": 1})[1]`), "b")
+
+	testErrorObject(t, testEval(`invert({"a": [1]})`), "unusable as hash key: ARRAY")
+	testErrorObject(t, testEval(`invert([1])`), "argument to `invert` must be a HASH, got=ARRAY")
+}
+
+func TestConversionBuiltins(t *testing.T) {
+	testIntegerObject(t, testEval(`int("42")`), 42)
+	testIntegerObject(t, testEval(`int("-7")`), -7)
+	testIntegerObject(t, testEval(`int(5)`), 5)
+	testIntegerObject(t, testEval(`int(str(123)) + 1`), 124)
+
+	testStringObject(t, testEval(`str(42)`), "42")
+	testStringObject(t, testEval(`str([1, 2])`), "[1, 2]")
+	testStringObject(t, testEval(`str(true)`), "true")
+	testStringObject(t, testEval(`str("x")`), "x")
+
+	testErrorObject(t, testEval(`int("abc")`), `could not convert "abc" to INTEGER`)
+	testErrorObject(t, testEval(`int("")`), `could not convert "" to INTEGER`)
+	testErrorObject(t, testEval(`int([1])`), "argument to `int` must be a STRING, INTEGER or FLOAT, got=ARRAY")
+	testErrorObject(t, testEval(`str()`), "wrong number of arguments. got=0, want=1")
+}
+
+func TestFrequenciesBuiltin(t *testing.T) {
+	input := `let f = frequencies(["a", "b", "a", "c", "a"]); [f["a"], f["b"], f["c"]]`
+
+	if evaluated := testEval(input); evaluated.Inspect() != "[3, 1, 1]" {
+		t.Errorf("wrong counts. got=%s", evaluated.Inspect())
+	}
+
+	testIntegerObject(t, testEval(`frequencies([1, 2, 1, true])[1]`), 2)
+	testNullObject(t, testEval(`frequencies([])["a"]`))
+
+	testErrorObject(t, testEval(`frequencies([[1]])`), "unusable as hash key: ARRAY")
+	testErrorObject(t, testEval(`frequencies("abc")`), "argument to `frequencies` must be an ARRAY, got=STRING")
+}
+
+func TestDeleteBuiltin(t *testing.T) {
+	input := `let h = {"a": 1, "b": 2}; let d = delete(h, "a"); [d["a"], d["b"], h["a"]]`
+
+	if evaluated := testEval(input); evaluated.Inspect() != "[null, 2, 1]" {
+		t.Errorf("wrong hash after delete. got=%s", evaluated.Inspect())
+	}
+
+	testBooleanObject(t, testEval(`is_subset({"a": 1}, delete({"a": 1}, "missing"))`), true)
+
+	testErrorObject(t, testEval(`delete({"a": 1}, [1])`), "unusable as hash key: ARRAY")
+	testErrorObject(t, testEval(`delete([1], 0)`), "first argument to `delete` must be a HASH, got=ARRAY")
+}
+
+func TestChunkAndWindows(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`chunk([1, 2, 3, 4], 2)`, "[[1, 2], [3, 4]]"},
+		{`chunk([1, 2, 3, 4, 5], 2)`, "[[1, 2], [3, 4], [5]]"},
+		{`chunk([1, 2], 5)`, "[[1, 2]]"},
+		{`chunk([], 3)`, "[]"},
+		{`windows([1, 2, 3, 4], 2)`, "[[1, 2], [2, 3], [3, 4]]"},
+		{`windows([1, 2, 3, 4, 5], 3)`, "[[1, 2, 3], [2, 3, 4], [3, 4, 5]]"},
+		{`windows([1, 2], 3)`, "[]"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. expected=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`chunk([1], 0)`), "second argument to `chunk` must be positive, got=0")
+	testErrorObject(t, testEval(`windows([1], -1)`), "second argument to `windows` must be positive, got=-1")
+	testErrorObject(t, testEval(`chunk("ab", 1)`), "first argument to `chunk` must be an ARRAY, got=STRING")
+	testErrorObject(t, testEval(`windows([1], "1")`), "second argument to `windows` must be an INTEGER, got=STRING")
+}
+
+func TestKeysAndValues(t *testing.T) {
+	testIntegerObject(t, testEval(`let h = {"a": 1, "b": 2, "c": 3}; len(keys(h))`), 3)
+	testIntegerObject(t, testEval(`let h = {"a": 1, "b": 2, "c": 3}; len(values(h))`), 3)
+	testIntegerObject(t, testEval(`len(keys({}))`), 0)
+
+	// keys and values line up with each other
+	input := `let h = {"x": 10, "y": 20, "z": 30}; let k = keys(h); let v = values(h); h[k[1]] == v[1]`
+	testBooleanObject(t, testEval(input), true)
+
+	testErrorObject(t, testEval(`keys([1])`), "argument to `keys` must be a HASH, got=ARRAY")
+	testErrorObject(t, testEval(`values(1)`), "argument to `values` must be a HASH, got=INTEGER")
+}
+
+func TestConditionalAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = false; x ||= 5; x", 5},
+		{"let x = 3; x ||= 5; x", 3},
+		{"let x = 0; x ||= 5; x", 0},
+		{"let x = 3; x &&= 5; x", 5},
+		{"let x = false; x &&= 5; x", false},
+		{"let x = 1; x ||= y; x", 1},
+		{"let x = 1; let f = fn() { x ||= 2; x &&= 9 }; f(); x", 9},
+		{"y ||= 5", "identifier not found `y`"},
+		{"let x = false; x ||= y", "identifier not found: y"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		switch expected := test.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestSortEntriesByValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`sort_entries_by_value(frequencies(split("b a c a b a", " ")))`,
+			"[[c, 1], [b, 2], [a, 3]]",
+		},
+		{`sort_entries_by_value({"x": "pear", "y": "apple"})`, "[[y, apple], [x, pear]]"},
+		{`sort_entries_by_value({"b": 1, "a": 1})`, "[[b, 1], [a, 1]]"},
+		{`sort_entries_by_value({})`, "[]"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong entries for %s. expected=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`sort_entries_by_value({"a": 1, "b": [1]})`), "values of `sort_entries_by_value` must be INTEGER or STRING, got=ARRAY")
+	testErrorObject(t, testEval(`sort_entries_by_value({"a": 1, "b": "1"})`), "values of `sort_entries_by_value` are not comparable: INTEGER and STRING")
+	testErrorObject(t, testEval(`sort_entries_by_value([1])`), "argument to `sort_entries_by_value` must be a HASH, got=ARRAY")
+}
+
+func TestErrorLineNumbers(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedLine int
+		expected     string
+	}{
+		{"let a = 1;\nlet b = 2;\n\nfoo + a", 4, "ERROR: line 4: identifier not found: foo"},
+		{"let a = 1;\nlet b = a +\n  true;", 2, "ERROR: line 2: type error: cannot use BOOLEAN in arithmetic"},
+		{"let f = fn() {\n  -true\n};\nf()", 2, "ERROR: line 2: type error: cannot use BOOLEAN in arithmetic"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+		errObj, ok := evaluated.(*object.Error)
+
+		if !ok {
+			t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Line != test.expectedLine {
+			t.Errorf("wrong error line. expected=%d, got=%d", test.expectedLine, errObj.Line)
+		}
+
+		if errObj.Inspect() != test.expected {
+			t.Errorf("wrong error output. expected=%q, got=%q", test.expected, errObj.Inspect())
+		}
+	}
+}
+
+func TestFormatWidthAndAlignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`format("[{}]", "x")`, "[x]"},
+		{`format("[{:>10}]", "right")`, "[     right]"},
+		{`format("[{:<6}]", "left")`, "[left  ]"},
+		{`format("[{:^7}]", "mid")`, "[  mid  ]"},
+		{`format("[{:5}]", 42)`, "[   42]"},
+		{`format("[{:5}]", "ab")`, "[ab   ]"},
+		{`format("{:08}", 42)`, "00000042"},
+		{`format("{:05}", -42)`, "-0042"},
+		{`format("{:2}", 12345)`, "12345"},
+		{`format("{:<4}|{:>4}", 1, 2)`, "1   |   2"},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`format("{:x}", 1)`), "invalid format spec `x`")
+	testErrorObject(t, testEval(`format("{:99999999999999}", 1)`), "format width 99999999999999 exceeds the limit of 268435456")
+	testErrorObject(t, testEval(`format("{:>999999999999999999999}", 1)`), "invalid format spec `>999999999999999999999`")
+	testErrorObject(t, testEval(`format("{name}", 1)`), "invalid placeholder in `format`: {name}")
+	testErrorObject(t, testEval(`format("{:>3", 1)`), "unclosed placeholder in `format`: \"{:>3\"")
+	testErrorObject(t, testEval(`format(1)`), "first argument to `format` must be a STRING, got=INTEGER")
+}
+
+func TestTernaryExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true ? 1 : 2", 1},
+		{"false ? 1 : 2", 2},
+		{"1 > 2 ? 10 : 20", 20},
+		{"let x = 5; x > 3 ? x * 2 : x", 10},
+		{"let n = 0; n == 0 ? 100 : n == 1 ? 200 : 300", 100},
+		{"let n = 1; n == 0 ? 100 : n == 1 ? 200 : 300", 200},
+		{"let n = 2; n == 0 ? 100 : n == 1 ? 200 : 300", 300},
+		{"true ? 1 : missing", 1},
+		{"false ? missing : 2", 2},
+		{"missing ? 1 : 2", "identifier not found: missing"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		switch expected := test.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestParseCsv(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`parse_csv("a,b,c\n1,2,3")`, "[[a, b, c], [1, 2, 3]]"},
+		{`parse_csv("name,note\n\"Smith, John\",\"said \"\"hi\"\"\"")`, `[[name, note], [Smith, John, said "hi"]]`},
+		{`len(parse_csv("\"a,b\",c")[0])`, "2"},
+		{`parse_csv("a\nb,c")`, "[[a], [b, c]]"},
+		{`parse_csv("")`, "[]"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`parse_csv("a,\"b")`), `could not parse csv: parse error on line 1, column 5: extraneous or missing " in quoted-field`)
+	testErrorObject(t, testEval(`parse_csv(1)`), "argument to `parse_csv` must be a STRING, got=INTEGER")
+}
+
+func TestIndexAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let a = [1, 2, 3]; a[1] = 20; a[1]", 20},
+		{"let a = [1, 2, 3]; a[-1] = 30; a[2]", 30},
+		{"let a = [1, 2, 3]; a[0] = 5", 5},
+		{"let a = [1, 2]; let b = a; b[0] = 9; a[0]", 9},
+		{"let a = [[1, 2], [3, 4]]; a[1][0] = 7; a[1][0]", 7},
+		{"let a = [1]; let set = fn(arr) { arr[0] = 42 }; set(a); a[0]", 42},
+		{"let a = [1, 2, 3]; a[3] = 1", "index out of range: 3"},
+		{"let a = [1, 2, 3]; a[-4] = 1", "index out of range: -4"},
+		{"let a = [1]; a[0] = missing", "identifier not found: missing"},
+		{`let s = "abc"; s[0] = "x"`, "index assignment not supported: STRING[INTEGER]"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		switch expected := test.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestToCsv(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`to_csv([["a", "b"], [1, true]])`, "a,b\n1,true\n"},
+		{`to_csv([["Smith, John", "said \"hi\""]])`, "\"Smith, John\",\"said \"\"hi\"\"\"\n"},
+		{`to_csv([])`, ""},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	roundTrip := testEval(`let rows = [["name", "note"], ["Smith, John", "a \"quote\""]]; parse_csv(to_csv(rows))`)
+
+	if roundTrip.Inspect() != `[[name, note], [Smith, John, a "quote"]]` {
+		t.Errorf("round trip through csv changed the rows. got=%s", roundTrip.Inspect())
+	}
+
+	testErrorObject(t, testEval(`to_csv([["a"], "b"])`), "rows of `to_csv` must be ARRAY, got=STRING at index 1")
+	testErrorObject(t, testEval(`to_csv("a")`), "argument to `to_csv` must be an ARRAY, got=STRING")
+}
+
+func TestCaseConversion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`snake_case("helloWorld")`, "hello_world"},
+		{`snake_case("HelloBigWorld")`, "hello_big_world"},
+		{`snake_case("parseHTTPRequest")`, "parse_http_request"},
+		{`snake_case("userID")`, "user_id"},
+		{`snake_case("already_snake")`, "already_snake"},
+		{`snake_case("kebab-case words")`, "kebab_case_words"},
+		{`camel_case("hello_world")`, "helloWorld"},
+		{`camel_case("parse_http_request")`, "parseHttpRequest"},
+		{`camel_case("HTTPServer")`, "httpServer"},
+		{`camel_case("some words here")`, "someWordsHere"},
+		{`camel_case(snake_case("loadConfigFile"))`, "loadConfigFile"},
+		{`snake_case(camel_case("load_config_file"))`, "load_config_file"},
+		{`camel_case("")`, ""},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`snake_case(1)`), "argument to `snake_case` must be a STRING, got=INTEGER")
+	testErrorObject(t, testEval(`camel_case([])`), "argument to `camel_case` must be a STRING, got=ARRAY")
+}
+
+func TestHashIndexAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let h = {}; h["a"] = 1; h["a"]`, 1},
+		{`let h = {"a": 1}; h["a"] = 2; h["a"]`, 2},
+		{`let h = {"a": 1}; h["b"] = 2; h["a"] + h["b"]`, 3},
+		{`let h = {}; h[1] = 10; h[true] = 20; h[1] + h[true]`, 30},
+		{`let h = {}; let g = h; g["x"] = 5; h["x"]`, 5},
+		{`let counts = {}; for (w in ["a", "b", "a"]) { counts[w] = (w in counts) ? counts[w] + 1 : 1 }; counts["a"]`, 2},
+		{`let h = {}; h[[1]] = 1`, "unusable as hash key: ARRAY"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		switch expected := test.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestTraceMode(t *testing.T) {
+	var out strings.Builder
+
+	Trace, TraceOut = true, &out
+	defer func() { Trace, TraceOut = false, os.Stdout }()
+
+	testEval("1 + -2")
+
+	expected := `Program
+  ExpressionStatement
+    InfixExpression
+      IntegerLiteral
+      PrefixExpression
+        IntegerLiteral
+`
+
+	if out.String() != expected {
+		t.Errorf("wrong trace output. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestTraceModeOffByDefault(t *testing.T) {
+	var out strings.Builder
+
+	TraceOut = &out
+	defer func() { TraceOut = os.Stdout }()
+
+	testEval("1 + 2")
+
+	if out.Len() != 0 {
+		t.Errorf("trace output written while disabled. got=%q", out.String())
+	}
+}
+
+func TestNullLiteral(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"null", nil},
+		{"nil", nil},
+		{"let x = null; x", nil},
+		{"null == nil", true},
+		{"let h = {}; h[\"missing\"] == null", true},
+		{"let x = 1; x == null", false},
+		{"[1, 2][5] != nil", false},
+		{"if (null) { 1 } else { 2 }", 2},
+		{"let f = fn() {}; f() == null", true},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		switch expected := test.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestLazyRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`xrange(5)`, "xrange(0, 5, 1)"},
+		{`map(xrange(5), fn(x) { x * x })`, "[0, 1, 4, 9, 16]"},
+		{`map(xrange(2, 10, 3), fn(x) { x })`, "[2, 5, 8]"},
+		{`map(xrange(5, 0, -2), fn(x) { x })`, "[5, 3, 1]"},
+		{`map(xrange(3, 3), fn(x) { x })`, "[]"},
+		{`filter(xrange(10), fn(x) { x % 3 == 0 })`, "[0, 3, 6, 9]"},
+		{`scan(xrange(1, 4), 0, fn(acc, x) { acc + x })`, "[0, 1, 3, 6]"},
+		{`len(xrange(0, 10, 3))`, "4"},
+		{`len(xrange(10, 0))`, "0"},
+		{`len(xrange(0, 9223372036854775807, 2))`, "4611686018427387904"},
+		{`map(xrange(0, 9223372036854775807, 4611686018427387904), fn(x) { x })`, "[0, 4611686018427387904]"},
+		{`map(xrange(9223372036854775807, 0, -4611686018427387904), fn(x) { x })`, "[9223372036854775807, 4611686018427387903]"},
+		{`let s = 0; for (i, x in xrange(10, 13)) { s = s + i * x }; s`, "35"},
+		{`map([1, 2], fn(x) { x + 1 })`, "[2, 3]"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`xrange(0, 10, 0)`), "step of `xrange` must not be zero")
+	testErrorObject(t, testEval(`xrange("a")`), "arguments to `xrange` must be INTEGER, got=STRING")
+	testErrorObject(t, testEval(`map(1, fn(x) { x })`), "first argument to `map` must be an ARRAY or RANGE, got=INTEGER")
+	testErrorObject(t, testEval(`map(xrange(3), fn(x) { x + true })`), "type error: cannot use BOOLEAN in arithmetic")
+}
+
+func TestLazyRangeDoesNotMaterialize(t *testing.T) {
+	// A billion element range would not fit in memory if it was turned into an array
+	testIntegerObject(t, testEval(`len(xrange(1000000000))`), 1000000000)
+
+	input := `reduce(xrange(0, 1000000000, 10000000), 0, fn(acc, x) { acc + 1 })`
+	testIntegerObject(t, testEval(input), 100)
+
+	mapped := testEval(`map(xrange(0, 1000000000, 100000000), fn(x) { x / 100000000 })`)
+
+	if mapped.Inspect() != "[0, 1, 2, 3, 4, 5, 6, 7, 8, 9]" {
+		t.Errorf("wrong mapped range. got=%s", mapped.Inspect())
+	}
+}
+
+func TestArrayEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] != [1, 2]", false},
+		{"[] == []", true},
+		{"[1, 2] == [1, 2, 3]", false},
+		{"[1, 2] != [1]", true},
+		{"[1, 2] == [2, 1]", false},
+		{`[1, "a", true] == [1, "a", true]`, true},
+		{`[1, "a"] == [1, 1]`, false},
+		{"[[1, 2], [3]] == [[1, 2], [3]]", true},
+		{"[[1, 2], [3]] == [[1, 2], [4]]", false},
+		{"let a = [1]; a == a", true},
+		{"[1] in [[1], [2]]", true},
+		{"let a = [1]; a[0] = a; let b = [1]; b[0] = b; a == b", true},
+		{"let a = [1, 2]; a[0] = a; let b = [1, 3]; b[0] = b; a == b", false},
+		{"let a = [1]; a[0] = a; let b = [[1]]; b[0][0] = b; a == b", true},
+		{"let a = [1]; a[0] = a; [a] == [a]", true},
+	}
+
+	for _, test := range tests {
+		testBooleanObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval("[1] + [2]"), "unknown operator: ARRAY + ARRAY")
+}
+
+func TestHashEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`{"a": 1, "b": 2} == {"a": 1, "b": 2}`, true},
+		{`{"a": 1, "b": 2} == {"b": 2, "a": 1}`, true},
+		{`{} == {}`, true},
+		{`{"a": 1, "b": 2} == {"a": 1, "b": 3}`, false},
+		{`{"a": 1, "b": 2} != {"a": 1, "b": 3}`, true},
+		{`{"a": 1} == {"a": 1, "b": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{`{"a": [1, {"b": 2}]} == {"a": [1, {"b": 2}]}`, true},
+		{`{"a": [1, {"b": 2}]} == {"a": [1, {"b": 3}]}`, false},
+		{`{1: "x"} == {"1": "x"}`, false},
+		{`is_subset({"a": [1]}, {"a": [1], "b": 2})`, true},
+		{`let h = {}; h["s"] = h; let g = {}; g["s"] = g; h == g`, true},
+		{`let h = {"x": 1}; h["s"] = h; let g = {"x": 2}; g["s"] = g; h == g`, false},
+		{`let h = {}; h["s"] = [h]; let g = {}; g["s"] = [g]; h == g`, true},
+		{`let h = {}; h["s"] = h; equal(h, h)`, true},
+	}
+
+	for _, test := range tests {
+		testBooleanObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`{} + {}`), "unknown operator: HASH + HASH")
+}
+
+func TestCustomEquality(t *testing.T) {
+	setup := `
+	let sameId = fn(a, b) { a["id"] == b["id"] };
+	let u1 = {"__eq": sameId, "id": 1, "name": "ann"};
+	let u2 = {"__eq": sameId, "id": 1, "name": "anne"};
+	let u3 = {"__eq": sameId, "id": 2, "name": "ann"};
+	`
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"u1 == u2", true},
+		{"u1 != u2", false},
+		{"u1 == u3", false},
+		{"equal(u1, u2)", true},
+		{"equal(u1, u3)", false},
+		{"[u1] == [u2]", true},
+		{"u2 in [u1]", true},
+		{`{"id": 1} == {"id": 1, "name": "ann"}`, false},
+		{`equal({"a": [1]}, {"a": [1]})`, true},
+		{`equal(1, 1)`, true},
+		{`equal(1, "1")`, false},
+	}
+
+	for _, test := range tests {
+		testBooleanObject(t, testEval(setup+test.input), test.expected)
+	}
+
+	input := `let broken = {"__eq": fn(a, b) { a + b }}; broken == {}`
+	testErrorObject(t, testEval(input), "unknown operator: HASH + HASH")
+}
+
+func TestPrettyBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`pretty(5)`, "5"},
+		{`pretty("monkey")`, "monkey"},
+		{`pretty([])`, "[]"},
+		{
+			`pretty([1, [2, 3], {"b": [], "a": 1}])`,
+			"[\n  1,\n  [\n    2,\n    3\n  ],\n  {\n    b: [],\n    a: 1\n  }\n]",
+		},
+		{
+			`let a = [1, 2]; a[1] = a; pretty(a)`,
+			"[\n  1,\n  ...\n]",
+		},
+		{
+			`let inner = [1]; pretty([inner, inner])`,
+			"[\n  [\n    1\n  ],\n  [\n    1\n  ]\n]",
+		},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+}
+
+func TestStringComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"apple" < "banana"`, true},
+		{`"banana" < "apple"`, false},
+		{`"banana" > "apple"`, true},
+		{`"apple" <= "apple"`, true},
+		{`"apple" >= "apple"`, true},
+		{`"apple" >= "banana"`, false},
+		{`"app" < "apple"`, true},
+		{`"apple" == "apple"`, true},
+		{`"apple" != "apple"`, false},
+		{`"apple" == "Apple"`, false},
+		{`"Zebra" < "apple"`, true},
+		{`"" < "a"`, true},
+		{`1 <= 2`, true},
+		{`2 <= 2`, true},
+		{`3 <= 2`, false},
+		{`3 >= 2`, true},
+		{`1 >= 2`, false},
+	}
+
+	for _, test := range tests {
+		testBooleanObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`"a" / "b"`), "unknown operator: STRING / STRING")
+}
+
+func TestStringRepetition(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"ab" * 3`, "ababab"},
+		{`3 * "x"`, "xxx"},
+		{`"-" * 5`, "-----"},
+		{`"ab" * 0`, ""},
+		{`"ab" * -2`, ""},
+		{`"" * 10`, ""},
+		{`"a" + "b" * 2`, "abb"},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`"ab" + 1`), "type mismatch: STRING + INTEGER")
+	testErrorObject(t, testEval(`1 - "ab"`), "type mismatch: INTEGER - STRING")
+	testErrorObject(t, testEval(`"ab" * 9223372036854775807`),
+		"string repetition too long: 2 bytes * 9223372036854775807 exceeds the limit of 268435456 bytes")
+	testErrorObject(t, testEval(`9223372036854775807 * "ab"`),
+		"string repetition too long: 2 bytes * 9223372036854775807 exceeds the limit of 268435456 bytes")
+}
+
+func TestAssertEq(t *testing.T) {
+	testBooleanObject(t, testEval(`assert_eq(1 + 1, 2)`), true)
+	testBooleanObject(t, testEval(`assert_eq([1, {"a": "b"}], [1, {"a": "b"}])`), true)
+
+	testErrorObject(t, testEval(`assert_eq(1 + 1, 3)`), "assertion failed: expected=3, got=2")
+	testErrorObject(t, testEval(`assert_eq([1, 2], [1, 3])`), "assertion failed: expected=[1, 3], got=[1, 2]")
+	testErrorObject(t, testEval(`assert_eq("1", 1)`), "assertion failed: expected=1 (INTEGER), got=1 (STRING)")
+	testErrorObject(t, testEval(`assert_eq(1)`), "wrong number of arguments. got=1, want=2")
+
+	// A failed assertion stop the script like any other error
+	testErrorObject(t, testEval(`assert_eq(1, 2); 5`), "assertion failed: expected=2, got=1")
+}
+
+func TestDeepSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`deep_size(5)`, 1},
+		{`deep_size([])`, 1},
+		{`deep_size([1, 2, 3])`, 4},
+		{`deep_size({"a": 1})`, 3},
+		{`deep_size([1, [2, 3], {"k": [4]}])`, 9},
+		{`let inner = [1, 2]; deep_size([inner, inner])`, 4},
+		{`let a = [1]; a[0] = a; deep_size(a)`, 1},
+		{`let h = {}; h["self"] = h; deep_size(h)`, 2},
+	}
+
+	for _, test := range tests {
+		testIntegerObject(t, testEval(test.input), int64(test.expected))
+	}
+}
+
+func TestStrictArithmeticOperands(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"true + 1", "type error: cannot use BOOLEAN in arithmetic"},
+		{"1 - false", "type error: cannot use BOOLEAN in arithmetic"},
+		{"true * false", "type error: cannot use BOOLEAN in arithmetic"},
+		{"10 / true", "type error: cannot use BOOLEAN in arithmetic"},
+		{"true % 2", "type error: cannot use BOOLEAN in arithmetic"},
+		{"2 ** true", "type error: cannot use BOOLEAN in arithmetic"},
+		{"1 << true", "type error: cannot use BOOLEAN in arithmetic"},
+		{`"a" + true`, "type error: cannot use BOOLEAN in arithmetic"},
+		{"null + 1", "type error: cannot use NULL in arithmetic"},
+		{"1 * nil", "type error: cannot use NULL in arithmetic"},
+		{"[1][5] + 1", "type error: cannot use NULL in arithmetic"},
+		{"-null", "type error: cannot use NULL in arithmetic"},
+		{"-true", "type error: cannot use BOOLEAN in arithmetic"},
+	}
+
+	for _, test := range tests {
+		testErrorObject(t, testEval(test.input), test.expected)
+	}
+
+	// Comparisons are not arithmetic and keep their own rules
+	testBooleanObject(t, testEval("true == true"), true)
+	testBooleanObject(t, testEval("null != 1"), true)
+}
+
+func TestOrderedKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`ordered_keys({"z": 1, "a": 2, "m": 3})`, "[z, a, m]"},
+		{`let h = {"b": 1}; h["a"] = 2; h["c"] = 3; h["b"] = 4; ordered_keys(h)`, "[b, a, c]"},
+		{`ordered_keys(delete({"z": 1, "a": 2, "m": 3}, "a"))`, "[z, m]"},
+		{`ordered_keys(frequencies(["y", "x", "y"]))`, "[y, x]"},
+		{`ordered_keys(reorder({"z": 1, "a": 2, "m": 3}, ["m", "z", "a"]))`, "[m, z, a]"},
+		{`ordered_keys({})`, "[]"},
+		{
+			`let h = {"name": "ann", "age": 3, "city": "kl"};
+			join(map(ordered_keys(h), fn(k) { k + "=" + str(h[k]) }), "&")`,
+			"name=ann&age=3&city=kl",
+		},
+		{
+			`let h = reorder({"name": "ann", "age": 3}, ["age", "name"]);
+			join(map(ordered_keys(h), fn(k) { k + "=" + str(h[k]) }), "&")`,
+			"age=3&name=ann",
+		},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`reorder({"a": 1, "b": 2}, ["a"])`), "`reorder` is missing 1 key(s) of the hash")
+	testErrorObject(t, testEval(`reorder({"a": 1}, ["a", "b"])`), "key b not found in hash")
+	testErrorObject(t, testEval(`reorder({"a": 1}, ["a", "a"])`), "duplicate key a in `reorder`")
+	testErrorObject(t, testEval(`ordered_keys([])`), "argument to `ordered_keys` must be a HASH, got=ARRAY")
+}
+
+func TestErrorsIncludeExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = 10; let b = 0; a / b", "division by zero in (a / b)"},
+		{"let a = 10; let b = 0; a % b", "modulo by zero in (a % b)"},
+		{"let b = 0; 1 + 2 * (3 / b)", "division by zero in (3 / b)"},
+		{"let f = fn(x) { 100 / (x - 1) }; f(1)", "division by zero in (100 / (x - 1))"},
+	}
+
+	for _, test := range tests {
+		testErrorObject(t, testEval(test.input), test.expected)
+	}
+
+	testIntegerObject(t, testEval("let b = 2; 10 / b"), 5)
+}
+
+func TestInterleave(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`interleave([1, 2, 3], ["a", "b", "c"])`, "[1, a, 2, b, 3, c]"},
+		{`interleave([1, 2, 3], ["a"])`, "[1, a]"},
+		{`interleave([1, 2], [3, 4], [5, 6])`, "[1, 3, 5, 2, 4, 6]"},
+		{`interleave([1, 2])`, "[1, 2]"},
+		{`interleave([], [1])`, "[]"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`interleave([1], "a")`), "arguments to `interleave` must be ARRAY, got=STRING")
+	testErrorObject(t, testEval(`interleave()`), "wrong number of arguments. got=0, want>=1")
+}
+
+func TestLocals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let x = 5; locals()["x"]`, "5"},
+		{`let x = 5; let y = "two"; ordered_keys(locals())`, "[x, y]"},
+		{`let x = 5; let f = fn(a) { let b = 2; ordered_keys(locals()) }; f(1)`, "[a, b]"},
+		{`let f = fn() { locals() }; len(ordered_keys(f()))`, "0"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`locals(1)`), "wrong number of arguments. got=1, want=0")
+	testErrorObject(t, testEval(`map([1], locals)`), "`locals` must be called directly")
+}
+
+func TestPop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`pop([1, 2, 3])`, "[3, [1, 2]]"},
+		{`let a = [1, 2, 3]; pop(a); a`, "[1, 2, 3]"},
+		{`pop(["a"])`, "[a, []]"},
+		{`let a = [1, 2, 3]; pop(pop(a)[1])[0]`, "2"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`pop([])`), "cannot `pop` from an empty array")
+	testErrorObject(t, testEval(`pop("abc")`), "argument to `pop` must be an ARRAY, got=STRING")
+}
+
+func TestCurry(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let add = fn(a, b, c) { a + b + c }; curry(add, 3)(1)(2)(3)`, "6"},
+		{`let add = fn(a, b, c) { a + b + c }; let c = curry(add, 3); let one = c(1); [one(2)(3), one(10)(20)]`, "[6, 31]"},
+		{`let join3 = fn(a, b, c) { a + b + c }; curry(join3, 3)("a")("b")("c")`, "abc"},
+		{`curry(len, 1)("four")`, "4"},
+		{`map([1, 2], curry(fn(a, b) { a * b }, 2)(10))`, "[10, 20]"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`curry(fn(a, b) { a + b }, 2)(1, 2)`), "wrong number of arguments. got=2, want=1")
+	testErrorObject(t, testEval(`curry(1, 2)`), "first argument to `curry` must be a FUNCTION, got=INTEGER")
+	testErrorObject(t, testEval(`curry(len, 0)`), "arity to `curry` must be at least 1, got=0")
+}
+
+func TestFormatPlaceholders(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`format("{} + {} = {}", 1, 2, 3)`, "1 + 2 = 3"},
+		{`format("{} is {}", [1, 2], true)`, "[1, 2] is true"},
+		{`format("no placeholders")`, "no placeholders"},
+		{`format("{{}} and {}", "x")`, "{} and x"},
+		{`format("{{{}}}", 5)`, "{5}"},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`format("{} + {} = {}", 1, 2)`), "not enough arguments to `format`. got=2")
+	testErrorObject(t, testEval(`format("{}", 1, 2)`), "too many arguments to `format`. got=2, want=1")
+}
+
+func TestBuiltinsPerInterpreter(t *testing.T) {
+	greeter := object.NewEnvironment()
+	greeter.RegisterBuiltin("greet", func(args ...object.Object) object.Object {
+		return &object.String{Value: "hello " + args[0].Inspect()}
+	})
+
+	shouter := object.NewEnvironment()
+	shouter.RegisterBuiltin("greet", func(args ...object.Object) object.Object {
+		return &object.String{Value: "HEY " + args[0].Inspect() + "!"}
+	})
+	shouter.RegisterBuiltin("len", func(args ...object.Object) object.Object {
+		return &object.Integer{Value: -1}
+	})
+
+	run := func(input string, env *object.Environment) object.Object {
+		return Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+	}
+
+	testStringObject(t, run(`greet("ann")`, greeter), "hello ann")
+	testStringObject(t, run(`greet("ann")`, shouter), "HEY ann!")
+
+	// Custom builtins are visible from nested scopes
+	testStringObject(t, run(`let f = fn(x) { greet(x) }; f("bob")`, greeter), "hello bob")
+
+	// Overriding a default only affect the interpreter that registered it
+	testIntegerObject(t, run(`len("abc")`, shouter), -1)
+	testIntegerObject(t, run(`len("abc")`, greeter), 3)
+
+	testErrorObject(t, testEval(`greet("ann")`), "identifier not found: greet")
+
+	// Registering from an enclosed scope register for the whole interpreter
+	object.NewEnclosedEnvironment(greeter).RegisterBuiltin("wave", func(args ...object.Object) object.Object {
+		return &object.String{Value: "wave"}
+	})
+	testStringObject(t, run(`wave()`, greeter), "wave")
+}
+
+func TestVersionBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`ordered_keys(version())`, "[features, strict_indexing, trace, version]"},
+		{`version()["version"]`, VERSION},
+		{`version()["strict_indexing"]`, "false"},
+		{`"ternary" in version()["features"]`, "true"},
+		{`"goto" in version()["features"]`, "false"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	StrictIndexing = true
+	defer func() { StrictIndexing = false }()
+
+	testBooleanObject(t, testEval(`version()["strict_indexing"]`), true)
+	testErrorObject(t, testEval(`version(1)`), "wrong number of arguments. got=1, want=0")
+}
+
+func TestReadFileBuiltin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.txt")
+
+	if err := os.WriteFile(path, []byte("line one\nline two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testStringObject(t, testEval(fmt.Sprintf(`read_file(%q)`, path)), "line one\nline two\n")
+	testIntegerObject(t, testEval(fmt.Sprintf(`len(split(read_file(%q), "\n"))`, path)), 3)
+
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	testErrorObject(t, testEval(fmt.Sprintf(`read_file(%q)`, missing)),
+		fmt.Sprintf("could not read file: open %s: no such file or directory", missing))
+	testErrorObject(t, testEval(`read_file(1)`), "argument to `read_file` must be a STRING, got=INTEGER")
+}
+
+func TestCollectionSizeLimits(t *testing.T) {
+	MaxArrayLength, MaxHashSize = 3, 2
+	defer func() { MaxArrayLength, MaxHashSize = 0, 0 }()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`push([1, 2], 3)`, "[1, 2, 3]"},
+		{`push([1, 2, 3], 4)`, "ERROR: line 1: array length 4 exceeds the limit of 3"},
+		{`let a = [1]; let i = 0; while (true) { a = push(a, i); i = i + 1; }`, "ERROR: line 1: array length 4 exceeds the limit of 3"},
+		{`[1, 2, 3, 4]`, "ERROR: line 1: array length 4 exceeds the limit of 3"},
+		{`{"a": 1, "b": 2, "c": 3}`, "ERROR: line 1: hash size 3 exceeds the limit of 2"},
+		{`let h = {"a": 1, "b": 2}; h["a"] = 10; h["a"]`, "10"},
+		{`let h = {"a": 1, "b": 2}; h["c"] = 3`, "ERROR: line 1: hash size 3 exceeds the limit of 2"},
+		{`range(3)`, "[0, 1, 2]"},
+		{`range(4)`, "ERROR: line 1: array length 4 exceeds the limit of 3"},
+		{`generate(4, fn(i) { i })`, "ERROR: line 1: array length 4 exceeds the limit of 3"},
+		{`map(xrange(1000000000000), fn(x) { x })`, "ERROR: line 1: array length 4 exceeds the limit of 3"},
+		{`filter(xrange(1000000000000), fn(x) { true })`, "ERROR: line 1: array length 4 exceeds the limit of 3"},
+		{`scan(xrange(1000000000000), 0, fn(acc, x) { x })`, "ERROR: line 1: array length 4 exceeds the limit of 3"},
+		{`split("a,b,c,d", ",")`, "ERROR: line 1: array length 4 exceeds the limit of 3"},
+		{`frequencies(["a", "b", "c"])`, "ERROR: line 1: hash size 3 exceeds the limit of 2"},
+		{`deep_merge({"a": 1}, {"b": 2, "c": 3})`, "ERROR: line 1: hash size 3 exceeds the limit of 2"},
+		{`flatten_hash({"a": {"b": 1, "c": 2, "d": 3}})`, "ERROR: line 1: hash size 3 exceeds the limit of 2"},
+		{`partition(xrange(100), fn(x) { true })`, "ERROR: line 1: array length 4 exceeds the limit of 3"},
+		{`partition(xrange(100), fn(x) { false })`, "ERROR: line 1: array length 4 exceeds the limit of 3"},
+		{`partition([1, 2, 3], fn(x) { x > 1 })`, "[[2, 3], [1]]"},
+		{`deep_merge({"n": {"a": 1}}, {"n": {"b": 2, "c": 3}})`, "ERROR: line 1: hash size 3 exceeds the limit of 2"},
+		{`deep_merge({"n": {"a": 1}}, {"n": {"b": 2}})`, "{n:{a:1, b:2}}"},
+		{`parse_csv("a,b,c,d")`, "ERROR: line 1: array length 4 exceeds the limit of 3"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestExitBuiltin(t *testing.T) {
+	codes := []int{}
+	exit = func(code int) { codes = append(codes, code) }
+	defer func() { exit = os.Exit }()
+
+	testNullObject(t, testEval(`exit()`))
+	testNullObject(t, testEval(`exit(2)`))
+	testEval(`let check = fn(ok) { if (!ok) { exit(3) } }; check(true); check(false)`)
+
+	expected := []int{0, 2, 3}
+
+	if len(codes) != len(expected) {
+		t.Fatalf("wrong exit calls. want=%v, got=%v", expected, codes)
+	}
+
+	for i, code := range expected {
+		if codes[i] != code {
+			t.Errorf("exit code[%d] wrong. want=%d, got=%d", i, code, codes[i])
+		}
+	}
+
+	testErrorObject(t, testEval(`exit("1")`), "argument to `exit` must be an INTEGER, got=STRING")
+	testErrorObject(t, testEval(`exit(1, 2)`), "wrong number of arguments. got=2, want=0 or 1")
+}
+
+func TestRotate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`rotate([1, 2, 3, 4, 5], 2)`, "[3, 4, 5, 1, 2]"},
+		{`rotate([1, 2, 3, 4, 5], -1)`, "[5, 1, 2, 3, 4]"},
+		{`rotate([1, 2, 3, 4, 5], 12)`, "[3, 4, 5, 1, 2]"},
+		{`rotate([1, 2, 3, 4, 5], -7)`, "[4, 5, 1, 2, 3]"},
+		{`rotate([1, 2, 3], 0)`, "[1, 2, 3]"},
+		{`rotate([], 3)`, "[]"},
+		{`let a = [1, 2]; rotate(a, 1); a`, "[1, 2]"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	testErrorObject(t, testEval(`rotate("abc", 1)`), "first argument to `rotate` must be an ARRAY, got=STRING")
+	testErrorObject(t, testEval(`rotate([1], "1")`), "second argument to `rotate` must be an INTEGER, got=STRING")
+}
+
+func TestPipeStr(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`pipe_str("  hi  ", [trim, upper])`, "HI"},
+		{`pipe_str("Monkey", [])`, "Monkey"},
+		{`pipe_str(" Ab ", [lower, fn(s) { s + "!" }, trim])`, "ab !"},
+		{`let exclaim = fn(s) { s + "!" }; pipe_str("hi", [exclaim, exclaim, upper])`, "HI!!"},
+	}
+
+	for _, test := range tests {
+		testStringObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`pipe_str("hi", [len])`), "function 0 in `pipe_str` must return a STRING, got=INTEGER")
+	testErrorObject(t, testEval(`pipe_str("hi", [upper, 1])`), "not a function: INTEGER")
+	testErrorObject(t, testEval(`pipe_str(1, [upper])`), "first argument to `pipe_str` must be a STRING, got=INTEGER")
+	testErrorObject(t, testEval(`pipe_str("hi", upper)`), "second argument to `pipe_str` must be an ARRAY, got=BUILTIN")
+}
+
+func TestDeepContains(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`deep_contains([1, [2, [3, 4]]], 4)`, true},
+		{`deep_contains({"a": [1, {"b": [5, 6]}]}, [5, 6])`, true},
+		{`deep_contains({"a": [1, {"b": 2}]}, {"b": 2})`, true},
+		{`deep_contains([[1, 2], "x"], "x")`, true},
+		{`deep_contains([1, [2, [3, 4]]], 5)`, false},
+		{`deep_contains([1, [2, [3, 4]]], [3])`, false},
+		{`deep_contains({"a": 1}, "a")`, false},
+		{`deep_contains([1, 2], "1")`, false},
+		{`deep_contains([], 1)`, false},
+		{`let a = [1, [2]]; a[0] = a; deep_contains(a, 3)`, false},
+		{`let a = [1, [2]]; a[0] = a; deep_contains(a, [2])`, true},
+		{`let h = {"x": 1}; h["self"] = h; deep_contains(h, 1)`, true},
+		{`let h = {"x": 1}; h["self"] = h; deep_contains([h], h)`, true},
+		{`let a = [0]; a[0] = a; deep_contains([a], [a])`, true},
+		{`let a = [0]; a[0] = a; let b = [0]; b[0] = b; deep_contains([[a]], b)`, true},
+		{`let a = [0]; a[0] = a; deep_contains([[1, 2]], a)`, false},
+	}
+
+	for _, test := range tests {
+		testBooleanObject(t, testEval(test.input), test.expected)
+	}
+
+	testErrorObject(t, testEval(`deep_contains(1, 1)`), "first argument to `deep_contains` must be an ARRAY or HASH, got=INTEGER")
+}
+
+func TestFloatExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"3.14", "3.14"},
+		{"-2.5", "-2.5"},
+		{"1 + 2.5", "3.5"},
+		{"2.5 + 1", "3.5"},
+		{"0.5 * 4", "2"},
+		{"7 / 2.0", "3.5"},
+		{"7 / 2", "3"},
+		{"5.5 % 2", "1.5"},
+		{"2 ** 0.5 > 1.41", "true"},
+		{"10 - 0.25", "9.75"},
+		{"1.5 < 2", "true"},
+		{"2.5 >= 2.5", "true"},
+		{"1.5 > 2.5", "false"},
+		{"1 == 1.0", "true"},
+		{"[1] == [1.0]", "true"},
+		{"[1, [2]] == [1.0, [2.5]]", "false"},
+		{`{"a": 2} == {"a": 2.0}`, "true"},
+		{"equal(1, 1.0)", "true"},
+		{"equal(1, 1.5)", "false"},
+		{"1.0 in [1, 2]", "true"},
+		{"2 in [1.5, 2.0]", "true"},
+		{`"floats" in version()["features"]`, "true"},
+		{"0.1 + 0.2 == 0.3", "false"},
+		{"1.5 != 1.5", "false"},
+		{`equal([1.5, 2], [1.5, 2])`, "true"},
+		{`type(1.0)`, "FLOAT"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	if _, ok := testEval("1 + 2.5").(*object.Float); !ok {
+		t.Errorf("mixed arithmetic did not produce a Float")
+	}
+
+	testErrorObject(t, testEval("1.5 / 0"), "division by zero in (1.5 / 0)")
+	testErrorObject(t, testEval("3 % 0.0"), "modulo by zero in (3 % 0.0)")
+	testErrorObject(t, testEval("1.5 << 1"), "unknown operator: FLOAT << INTEGER")
+	testErrorObject(t, testEval("1.5 + true"), "type error: cannot use BOOLEAN in arithmetic")
+	testErrorObject(t, testEval(`{1.5: "x"}`), "unusable as hash key FLOAT")
+}
+
+func TestHashInsertionOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"z": 1, "a": 2, "m": 3}`, "{z:1, a:2, m:3}"},
+		{`let h = {"b": 1}; h["a"] = 2; h["b"] = 3; h`, "{b:3, a:2}"},
+		{`keys({"z": 1, "a": 2, "m": 3})`, "[z, a, m]"},
+		{`values({"z": 1, "a": 2, "m": 3})`, "[1, 2, 3]"},
+		{`let h = {"z": 1, "a": 2}; keys(h) == ordered_keys(h)`, "true"},
+		{`let out = []; for (k, v in {"z": 1, "a": 2, "m": 3}) { out = push(out, k) }; out`, "[z, a, m]"},
+		{`deep_merge({"z": {"y": 1}, "a": 2}, {"z": {"b": 3}, "c": 4})`, "{z:{y:1, b:3}, a:2, c:4}"},
+		{`flatten_hash({"z": {"y": 1, "b": [2]}, "a": 3})`, "{z.y:1, z.b[0]:2, a:3}"},
+		{`invert({"z": "x", "a": "y"})`, "{x:z, y:a}"},
+		{`invert({"z": 1, "a": 1})`, "{1:a}"},
+		{`stats([3, 1])`, "{count:2, max:3, mean:2, min:1, sum:4}"},
+	}
+
+	for _, test := range tests {
+		evaluated := testEval(test.input)
+
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("wrong result for %s. want=%s, got=%s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestFunctionArgumentCount(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let add = fn(a, b) { a + b }; add(1)`, "wrong number of arguments. got=1, want=2"},
+		{`let add = fn(a, b) { a + b }; add(1, 2, 3)`, "wrong number of arguments. got=3, want=2"},
+		{`curry(fn(a, b, c) { a + b + c }, 2)(1)(2)`, "wrong number of arguments. got=2, want=3"},
+		{`map([1, 2], fn(a, b) { a })`, "wrong number of arguments. got=1, want=2"},
+		{`cond([[fn(x) { true }, fn() { 1 }]])`, "wrong number of arguments. got=0, want=1"},
+		{`let eq = fn(a) { true }; {"__eq": eq} == {"__eq": eq}`, "wrong number of arguments. got=2, want=1"},
+	}
+
+	for _, test := range tests {
+		testErrorObject(t, testEval(test.input), test.expected)
+	}
+}
+
+func TestLanguageFeatures(t *testing.T) {
+	// Every snippet must evaluate to true
+	snippets := map[string]string{
+		"arrow_functions":        "let f = fn(x) => x + 1; f(1) == 2",
+		"break_continue":         "let i = 0; while (true) { i = i + 1; if (i < 3) { continue } break }; i == 3",
+		"comments":               "// line\n/* block */ true",
+		"comparisons":            "1 <= 1 && 2 >= 1",
+		"conditional_assignment": "let x = null; x ||= 5; x == 5",
+		"destructuring":          "let a = 1; let b = 2; [a, b] = [b, a]; a == 2",
+		"digit_separators":       "1_000 == 1000",
+		"floats":                 "1.5 + 1.5 == 3.0",
+		"for":                    "let sum = 0; for (let i = 0; i < 3; i = i + 1) { sum = sum + i }; sum == 3",
+		"for_in":                 "let sum = 0; for (x in [1, 2]) { sum = sum + x }; sum == 3",
+		"in":                     "2 in [1, 2] && 3 not in [1, 2]",
+		"index_assignment":       "let a = [1]; a[0] = 2; a[0] == 2",
+		"modulo":                 "7 % 3 == 1",
+		"null":                   "null == nil",
+		"pipe":                   "let double = fn(x) { x * 2 }; (2 |> double) == 4",
+		"power":                  "2 ** 3 == 8",
+		"ranges":                 "3 in range(5)",
+		"shifts":                 "1 << 3 == 8",
+		"slices":                 "[1, 2, 3][1:2] == [2]",
+		"string_escapes":         `len("a\tb") == 3`,
+		"ternary":                "true ? true : false",
+		"while":                  "let i = 0; while (i < 3) { i = i + 1 }; i == 3",
+	}
+
+	if len(snippets) != len(languageFeatures) {
+		t.Errorf("languageFeatures has %d entries, want=%d", len(languageFeatures), len(snippets))
+	}
+
+	for _, feature := range languageFeatures {
+		snippet, ok := snippets[feature]
+
+		if !ok {
+			t.Errorf("no snippet for feature %q", feature)
+			continue
+		}
+
+		testBooleanObject(t, testEval(snippet), true)
+	}
+}
+
+// --------------------------------
+// Private function
+// --------------------------------
+func testEval(input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+
+	return Eval(program, env)
+}
+
+func testIntegerObject(t *testing.T, _obj object.Object, expected int64) bool {
+	obj, ok := _obj.(*object.Integer)
+
+	if !ok {
+		t.Errorf("object is not Integer. got=%T (%+v)", _obj, _obj)
+		return false
+	}
+
+	if obj.Value != expected {
+		t.Errorf("object has wrong value. got=%d, want=%d", obj.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func testBooleanObject(t *testing.T, _obj object.Object, expected bool) bool {
+	obj, ok := _obj.(*object.Boolean)
+
+	if !ok {
+		t.Errorf("object is not Boolean. got=%T (%+v)", _obj, _obj)
+		return false
+	}
+
+	if obj.Value != expected {
+		t.Errorf("object has wrong value. got=%T, want=%t", obj.Value, expected)
+		return false
+	}
+
+	return true
+
+}
+
+func testNullObject(t *testing.T, obj object.Object) bool {
+	if obj != NULL {
+		t.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	return true
+}
+
+func testStringObject(t *testing.T, _obj object.Object, expected string) bool {
+	obj, ok := _obj.(*object.String)
+
+	if !ok {
+		t.Errorf("object is not String. got=%T (%+v)", _obj, _obj)
+		return false
+	}
+
+	if obj.Value != expected {
+		t.Errorf("object has wrong value. got=%q, want=%q", obj.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func testErrorObject(t *testing.T, _obj object.Object, expected string) bool {
+	obj, ok := _obj.(*object.Error)
+
+	if !ok {
+		t.Errorf("object is not Error. got=%T (%+v)", _obj, _obj)
+		return false
+	}
+
+	if obj.Message != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, obj.Message)
 		return false
 	}
 
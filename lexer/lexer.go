@@ -2,6 +2,7 @@ package lexer
 
 import (
 	"Monkey/token"
+	"strings"
 )
 
 type Lexer struct {
@@ -9,11 +10,14 @@ type Lexer struct {
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (after current char)
 	ch           byte // current char under examination
+	line         int  // line of the current char
+	column       int  // column of the current char
 }
 
 func New(input string) *Lexer {
 	l := &Lexer{
 		input: input,
+		line:  1,
 	}
 
 	l.readChar()
@@ -21,6 +25,13 @@ func New(input string) *Lexer {
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+
+	l.column += 1
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -40,16 +51,30 @@ func (l *Lexer) peekChar() byte {
 }
 
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
-
 	l.skipWitespace()
 
+	// Remember where the token start before reading it
+	line, column := l.line, l.column
+
+	tok := l.readToken()
+	tok.Line, tok.Column = line, column
+
+	return tok
+}
+
+func (l *Lexer) readToken() token.Token {
+	var tok token.Token
+
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
 			ch := l.ch
 			l.readChar()
 			tok = token.Token{Type: token.EQ, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.ARROW, Literal: string(ch) + string(l.ch)}
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
@@ -57,6 +82,9 @@ func (l *Lexer) NextToken() token.Token {
 	case ':':
 		tok = newToken(token.COLON, l.ch)
 
+	case '?':
+		tok = newToken(token.QUESTION, l.ch)
+
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
 
@@ -94,13 +122,65 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.SLASH, l.ch)
 
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '*' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.POW, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.ASTERISK, l.ch)
+		}
+
+	case '%':
+		tok = newToken(token.MODULO, l.ch)
 
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		if l.peekChar() == '>' {
+			l.readChar()
+
+			if l.peekChar() == '>' {
+				l.readChar()
+				tok = token.Token{Type: token.USHR, Literal: ">>>"}
+			} else {
+				tok = token.Token{Type: token.SHR, Literal: ">>"}
+			}
+		} else if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.GT_EQ, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.GT, l.ch)
+		}
 
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		if l.peekChar() == '<' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.SHL, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.LT_EQ, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.LT, l.ch)
+		}
+
+	case '|':
+		if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.PIPE, Literal: string(ch) + string(l.ch)}
+		} else if l.peekChar() == '|' {
+			tok = l.readConditionalAssign(token.OR_ASSIGN)
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+
+	case '&':
+		if l.peekChar() == '&' {
+			tok = l.readConditionalAssign(token.AND_ASSIGN)
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
 
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
@@ -124,6 +204,21 @@ func (l *Lexer) NextToken() token.Token {
 		} else if isDigit(l.ch) {
 			tok.Type = token.INT
 			tok.Literal = l.readNumber()
+
+			// A `.` followed by a digit continue the number as a float. eg: 3.14
+			if l.ch == '.' && isDigit(l.peekChar()) {
+				l.readChar()
+				tok.Type = token.FLOAT
+				tok.Literal += "." + l.readNumber()
+			}
+
+			// Separators are only for the reader, the parser receive the bare digits
+			if strings.HasSuffix(tok.Literal, "_") || strings.Contains(tok.Literal, "__") || strings.Contains(tok.Literal, "_.") {
+				tok.Type = token.ILLEGAL
+			} else {
+				tok.Literal = strings.ReplaceAll(tok.Literal, "_", "")
+			}
+
 			return tok // early exit since `readNumber` already call `readChar`
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
@@ -137,24 +232,63 @@ func (l *Lexer) NextToken() token.Token {
 func (l *Lexer) readIdentifier() string {
 	position := l.position
 
-	for isLetter(l.ch) {
+	// Digits are allowed after the first letter. eg: base64_encode
+	for isLetter(l.ch) || isDigit(l.ch) {
 		l.readChar()
 	}
 
 	return l.input[position:l.position]
 }
 
+// readNumber read the digits of a number including any `_` separator. eg: 1_000_000
 func (l *Lexer) readNumber() string {
 	position := l.position
-	for isDigit(l.ch) {
+	for isDigit(l.ch) || l.ch == '_' {
 		l.readChar()
 	}
 
 	return l.input[position:l.position]
 }
 
+// Skip whitespace and comments, since both are meaningless to the parser
 func (l *Lexer) skipWitespace() {
-	for l.isWhiteSpace() {
+	for {
+		switch {
+		case l.isWhiteSpace():
+			l.readChar()
+
+		case l.ch == '/' && l.peekChar() == '/':
+			l.skipLineComment()
+
+		case l.ch == '/' && l.peekChar() == '*':
+			l.skipBlockComment()
+
+		default:
+			return
+		}
+	}
+}
+
+// `// comment` run until the end of the line
+func (l *Lexer) skipLineComment() {
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+}
+
+// `/* comment */` run until the closing `*/` or EOF if its never closed.
+// Nested block comments are not supported, the first `*/` close the comment
+func (l *Lexer) skipBlockComment() {
+	l.readChar() // consume the `/`
+	l.readChar() // consume the `*`
+
+	for l.ch != 0 {
+		if l.ch == '*' && l.peekChar() == '/' {
+			l.readChar() // consume the `*`
+			l.readChar() // consume the `/`
+			return
+		}
+
 		l.readChar()
 	}
 }
@@ -163,8 +297,10 @@ func (l *Lexer) isWhiteSpace() bool {
 	return l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r'
 }
 
+// Read until the closing `"` processing escape sequences along the way.
+// Unknown escape like `\q` is kept as is, backslash included
 func (l *Lexer) readString() string {
-	position := l.readPosition
+	var out strings.Builder
 
 	for {
 		l.readChar()
@@ -172,9 +308,50 @@ func (l *Lexer) readString() string {
 		if l.ch == '"' || l.ch == 0 {
 			break
 		}
+
+		if l.ch == '\\' {
+			l.readChar() // consume the `\`
+
+			switch l.ch {
+			case 'n':
+				out.WriteByte('\n')
+			case 't':
+				out.WriteByte('\t')
+			case 'r':
+				out.WriteByte('\r')
+			case '"':
+				out.WriteByte('"')
+			case '\\':
+				out.WriteByte('\\')
+			case 0:
+				out.WriteByte('\\')
+				return out.String()
+			default:
+				out.WriteByte('\\')
+				out.WriteByte(l.ch)
+			}
+
+			continue
+		}
+
+		out.WriteByte(l.ch)
 	}
 
-	return l.input[position:l.position]
+	return out.String()
+}
+
+// readConditionalAssign read `||=` or `&&=`, a doubled operator without the `=` is illegal
+func (l *Lexer) readConditionalAssign(tokenType token.TokenType) token.Token {
+	literal := string(l.ch)
+	l.readChar()
+	literal += string(l.ch)
+
+	if l.peekChar() != '=' {
+		return token.Token{Type: token.ILLEGAL, Literal: literal}
+	}
+
+	l.readChar()
+	return token.Token{Type: tokenType, Literal: literal + string(l.ch)}
 }
 
 func isLetter(ch byte) bool {
@@ -19,8 +19,10 @@ func TestNextToken(t *testing.T) {
 	};
 
 	let result = add(five, ten);
-	!-/*5;
+	!-/ *5;
 	5 < 10 > 5;
+	10 % 3;
+	2 ** 3;
 
 	if (5 < 10) {
 		return true;
@@ -84,6 +86,14 @@ func TestNextToken(t *testing.T) {
 		{token.GT, ">"},
 		{token.INT, "5"},
 		{token.SEMICOLON, ";"},
+		{token.INT, "10"},
+		{token.MODULO, "%"},
+		{token.INT, "3"},
+		{token.SEMICOLON, ";"},
+		{token.INT, "2"},
+		{token.POW, "**"},
+		{token.INT, "3"},
+		{token.SEMICOLON, ";"},
 		{token.IF, "if"},
 		{token.LPAREN, "("},
 		{token.INT, "5"},
@@ -143,3 +153,311 @@ func runTest(input string, tests ExpectedToken, t *testing.T) {
 		}
 	}
 }
+
+func TestLoopKeywords(t *testing.T) {
+	input := `for (x in arr) { break; continue; }`
+
+	tests := ExpectedToken{
+		{token.FOR, "for"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.IN, "in"},
+		{token.IDENT, "arr"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.BREAK, "break"},
+		{token.SEMICOLON, ";"},
+		{token.CONTINUE, "continue"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	}
+
+	runTest(input, tests, t)
+}
+
+func TestNotInOperator(t *testing.T) {
+	input := `x not in arr`
+
+	tests := ExpectedToken{
+		{token.IDENT, "x"},
+		{token.NOT, "not"},
+		{token.IN, "in"},
+		{token.IDENT, "arr"},
+		{token.EOF, ""},
+	}
+
+	runTest(input, tests, t)
+}
+
+func TestArrowToken(t *testing.T) {
+	input := `fn(x) => x == 1`
+
+	tests := ExpectedToken{
+		{token.FUNCTION, "fn"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "x"},
+		{token.EQ, "=="},
+		{token.INT, "1"},
+		{token.EOF, ""},
+	}
+
+	runTest(input, tests, t)
+}
+
+func TestPipeToken(t *testing.T) {
+	input := `x |> f(_, 2)`
+
+	tests := ExpectedToken{
+		{token.IDENT, "x"},
+		{token.PIPE, "|>"},
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.IDENT, "_"},
+		{token.COMMA, ","},
+		{token.INT, "2"},
+		{token.RPAREN, ")"},
+		{token.EOF, ""},
+	}
+
+	runTest(input, tests, t)
+}
+
+func TestComments(t *testing.T) {
+	uncommented := `let x = 5 / 2;
+	let y = x * 3;`
+
+	commented := `// leading comment
+	let x = 5 / 2; // trailing comment
+	/* block
+	   comment */ let /* inline */ y = x * 3;
+	// comment at EOF without newline`
+
+	expected := ExpectedToken{}
+	l := New(uncommented)
+
+	for tok := l.NextToken(); ; tok = l.NextToken() {
+		expected = append(expected, ExpectedToken{{tok.Type, tok.Literal}}...)
+
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+
+	runTest(commented, expected, t)
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	input := `let x = 5; /* never closed
+	let y = 10;`
+
+	tests := ExpectedToken{
+		{token.LET, "let"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	runTest(input, tests, t)
+}
+
+func TestStringEscapes(t *testing.T) {
+	input := `"a\tb" "line1\nline2" "say \"hi\"" "back\\slash" "cr\r" "unknown\q"`
+
+	tests := ExpectedToken{
+		{token.STRING, "a\tb"},
+		{token.STRING, "line1\nline2"},
+		{token.STRING, `say "hi"`},
+		{token.STRING, `back\slash`},
+		{token.STRING, "cr\r"},
+		{token.STRING, `unknown\q`},
+		{token.EOF, ""},
+	}
+
+	runTest(input, tests, t)
+
+	tok := New(`"a\tb"`).NextToken()
+
+	if len(tok.Literal) != 3 {
+		t.Fatalf("literal has wrong length. expected=%d, got=%d", 3, len(tok.Literal))
+	}
+}
+
+func TestIdentifierWithDigits(t *testing.T) {
+	input := `base64_encode(x1) 2x`
+
+	tests := ExpectedToken{
+		{token.IDENT, "base64_encode"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x1"},
+		{token.RPAREN, ")"},
+		{token.INT, "2"},
+		{token.IDENT, "x"},
+		{token.EOF, ""},
+	}
+
+	runTest(input, tests, t)
+}
+
+func TestShiftOperators(t *testing.T) {
+	input := `a << 1 >> 2 >>> 3 > 4 < 5`
+
+	tests := ExpectedToken{
+		{token.IDENT, "a"},
+		{token.SHL, "<<"},
+		{token.INT, "1"},
+		{token.SHR, ">>"},
+		{token.INT, "2"},
+		{token.USHR, ">>>"},
+		{token.INT, "3"},
+		{token.GT, ">"},
+		{token.INT, "4"},
+		{token.LT, "<"},
+		{token.INT, "5"},
+		{token.EOF, ""},
+	}
+
+	runTest(input, tests, t)
+}
+
+func TestConditionalAssignOperators(t *testing.T) {
+	input := `a ||= 1; b &&= 2; c || d & e`
+
+	tests := ExpectedToken{
+		{token.IDENT, "a"},
+		{token.OR_ASSIGN, "||="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "b"},
+		{token.AND_ASSIGN, "&&="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "c"},
+		{token.ILLEGAL, "||"},
+		{token.IDENT, "d"},
+		{token.ILLEGAL, "&"},
+		{token.IDENT, "e"},
+		{token.EOF, ""},
+	}
+
+	runTest(input, tests, t)
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := `let x = 5;
+  x >= 10
+// comment
+"str" == y`
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{"let", 1, 1},
+		{"x", 1, 5},
+		{"=", 1, 7},
+		{"5", 1, 9},
+		{";", 1, 10},
+		{"x", 2, 3},
+		{">=", 2, 5},
+		{"10", 2, 8},
+		{"str", 4, 1},
+		{"==", 4, 7},
+		{"y", 4, 10},
+		{"", 4, 11},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - position of %q wrong. expected=%d:%d, got=%d:%d",
+				i, tok.Literal, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestTernaryTokens(t *testing.T) {
+	input := `a ? b : c`
+
+	tests := ExpectedToken{
+		{token.IDENT, "a"},
+		{token.QUESTION, "?"},
+		{token.IDENT, "b"},
+		{token.COLON, ":"},
+		{token.IDENT, "c"},
+		{token.EOF, ""},
+	}
+
+	runTest(input, tests, t)
+}
+
+func TestComparisonOperators(t *testing.T) {
+	input := `a <= b >= c < d > e`
+
+	tests := ExpectedToken{
+		{token.IDENT, "a"},
+		{token.LT_EQ, "<="},
+		{token.IDENT, "b"},
+		{token.GT_EQ, ">="},
+		{token.IDENT, "c"},
+		{token.LT, "<"},
+		{token.IDENT, "d"},
+		{token.GT, ">"},
+		{token.IDENT, "e"},
+		{token.EOF, ""},
+	}
+
+	runTest(input, tests, t)
+}
+
+func TestDigitSeparators(t *testing.T) {
+	input := `1_000_000 12_3 7 1__000 1000_ _100`
+
+	tests := ExpectedToken{
+		{token.INT, "1000000"},
+		{token.INT, "123"},
+		{token.INT, "7"},
+		{token.ILLEGAL, "1__000"},
+		{token.ILLEGAL, "1000_"},
+		{token.IDENT, "_100"},
+		{token.EOF, ""},
+	}
+
+	runTest(input, tests, t)
+}
+
+func TestFloatLiterals(t *testing.T) {
+	input := `3.14 1_000.5 0.25 7 1_.5 [1][0].5`
+
+	tests := ExpectedToken{
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "1000.5"},
+		{token.FLOAT, "0.25"},
+		{token.INT, "7"},
+		{token.ILLEGAL, "1_.5"},
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.RBRACKET, "]"},
+		{token.LBRACKET, "["},
+		{token.INT, "0"},
+		{token.RBRACKET, "]"},
+		{token.ILLEGAL, "."},
+		{token.INT, "5"},
+		{token.EOF, ""},
+	}
+
+	runTest(input, tests, t)
+}
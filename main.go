@@ -1,6 +1,10 @@
 package main
 
 import (
+	"Monkey/evaluator"
+	"Monkey/lexer"
+	"Monkey/object"
+	"Monkey/parser"
 	"Monkey/repl"
 	"fmt"
 	"os"
@@ -8,6 +12,11 @@ import (
 )
 
 func main() {
+	// Run the script file if one is given, everything after it is the script arguments
+	if len(os.Args) > 1 {
+		os.Exit(runFile(os.Args[1], os.Args[2:]))
+	}
+
 	user, err := user.Current()
 
 	if err != nil {
@@ -18,3 +27,35 @@ func main() {
 	fmt.Printf("Feel free to type in commands.\n")
 	repl.Start(os.Stdin, os.Stdout)
 }
+
+func runFile(path string, args []string) int {
+	source, err := os.ReadFile(path)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	evaluator.ScriptArgs = args
+
+	l := lexer.New(string(source))
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		for _, msg := range p.Errors() {
+			fmt.Fprintln(os.Stderr, msg)
+		}
+		return 1
+	}
+
+	evaluated := evaluator.Eval(program, object.NewEnvironment())
+
+	if errObj, ok := evaluated.(*object.Error); ok {
+		fmt.Fprintln(os.Stderr, errObj.Inspect())
+		return 1
+	}
+
+	return 0
+}
@@ -1,19 +1,39 @@
 package object
 
+import "sync"
+
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
-	return &Environment{store: s, outer: nil}
+	return &Environment{store: s, outer: nil, builtins: &builtinTable{fns: map[string]*Builtin{}}, state: &EvalState{}}
 }
 
 func NewEnclosedEnvironment(outerEnv *Environment) *Environment {
-	env := NewEnvironment()
-	env.outer = outerEnv
-	return env
+	s := make(map[string]Object)
+
+	// Every scope of an interpreter share the same builtins and evaluation state
+	return &Environment{store: s, outer: outerEnv, builtins: outerEnv.builtins, state: outerEnv.state}
 }
 
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store    map[string]Object
+	outer    *Environment
+	builtins *builtinTable
+	state    *EvalState
+}
+
+// EvalState is the bookkeeping the evaluator update while running. There is one per top level
+// environment, so interpreters evaluating in different goroutines never touch the same one
+type EvalState struct {
+	CallDepth    int // Current function call nesting
+	MaxCallDepth int // Deepest function call nesting, reset on every top level evaluation
+	TraceDepth   int // Indentation of the trace output
+}
+
+// builtinTable hold the custom builtins registered on a top level environment. It is guarded
+// since an embedder may register builtins while the interpreter is running in another goroutine
+type builtinTable struct {
+	mu  sync.RWMutex
+	fns map[string]*Builtin
 }
 
 func (e *Environment) Get(key string) (Object, bool) {
@@ -40,3 +60,52 @@ func (e *Environment) IsKey(key string) bool {
 
 	return ok
 }
+
+// Assign update the key in the nearest environment where it was defined
+func (e *Environment) Assign(key string, val Object) bool {
+	if _, ok := e.store[key]; ok {
+		e.store[key] = val
+		return true
+	}
+
+	if e.outer != nil {
+		return e.outer.Assign(key, val)
+	}
+
+	return false
+}
+
+// Locals return a copy of the bindings defined in this environment, outer environments excluded
+func (e *Environment) Locals() map[string]Object {
+	locals := make(map[string]Object, len(e.store))
+
+	for key, val := range e.store {
+		locals[key] = val
+	}
+
+	return locals
+}
+
+// RegisterBuiltin add a builtin to the interpreter this environment belong to. The table is shared
+// by every scope of it, so registering from an enclosed scope is visible from the top level one too.
+// A builtin with the same name as a default one take precedence over it
+func (e *Environment) RegisterBuiltin(name string, fn BuiltinFunction) {
+	e.builtins.mu.Lock()
+	defer e.builtins.mu.Unlock()
+
+	e.builtins.fns[name] = &Builtin{Fn: fn}
+}
+
+// Builtin return the custom builtin registered under `name`, if any
+func (e *Environment) Builtin(name string) (*Builtin, bool) {
+	e.builtins.mu.RLock()
+	defer e.builtins.mu.RUnlock()
+
+	fn, ok := e.builtins.fns[name]
+	return fn, ok
+}
+
+// State return the evaluation state shared by every scope of this interpreter
+func (e *Environment) State() *EvalState {
+	return e.state
+}
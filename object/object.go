@@ -5,6 +5,9 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -12,6 +15,7 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
@@ -21,6 +25,9 @@ const (
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
+	RANGE_OBJ        = "RANGE"
 )
 
 type Object interface {
@@ -58,6 +65,22 @@ func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: INTEGER_OBJ, Value: uint64(i.Value)}
 }
 
+// ----------------------------------------------------
+// Float Struct
+// ----------------------------------------------------
+// Float is deliberately not `Hashable`, rounding make `0.1 + 0.2` and `0.3` different keys
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Inspect() string {
+	return strconv.FormatFloat(f.Value, 'f', -1, 64)
+}
+
+func (f *Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
 // ----------------------------------------------------
 // Boolean Struct
 // ----------------------------------------------------
@@ -114,14 +137,45 @@ func (rv *ReturnValue) Type() ObjectType {
 	return RETURN_VALUE_OBJ
 }
 
+// ----------------------------------------------------
+//	Break Struct
+// ----------------------------------------------------
+type Break struct{} // Signal the enclosing loop to stop
+
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+func (b *Break) Type() ObjectType {
+	return BREAK_OBJ
+}
+
+// ----------------------------------------------------
+//	Continue Struct
+// ----------------------------------------------------
+type Continue struct{} // Signal the enclosing loop to skip to next iteration
+
+func (c *Continue) Inspect() string {
+	return "continue"
+}
+
+func (c *Continue) Type() ObjectType {
+	return CONTINUE_OBJ
+}
+
 // ----------------------------------------------------
 //	Error Struct
 // ----------------------------------------------------
 type Error struct {
 	Message string
+	Line    int // source line the error was raised on, 0 when unknown
 }
 
 func (e *Error) Inspect() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("ERROR: line %d: %s", e.Line, e.Message)
+	}
+
 	return "ERROR: " + e.Message
 }
 
@@ -236,6 +290,48 @@ type HashPair struct {
 
 type Hash struct {
 	Pairs map[HashKey]HashPair
+	Order []HashKey // Insertion order of the keys added through `Set`
+}
+
+// Set insert or overwrite a pair, a new key is remembered as the last inserted one
+func (h *Hash) Set(key HashKey, pair HashPair) {
+	if h.Pairs == nil {
+		h.Pairs = make(map[HashKey]HashPair)
+	}
+
+	if _, ok := h.Pairs[key]; !ok {
+		h.Order = append(h.Order, key)
+	}
+
+	h.Pairs[key] = pair
+}
+
+// OrderedPairs return the pairs in insertion order. Pairs stored straight into `Pairs` have no
+// known position, they come last ordered by the printed form of their key
+func (h *Hash) OrderedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	seen := make(map[HashKey]bool, len(h.Pairs))
+
+	for _, key := range h.Order {
+		if pair, ok := h.Pairs[key]; ok && !seen[key] {
+			seen[key] = true
+			pairs = append(pairs, pair)
+		}
+	}
+
+	rest := []HashPair{}
+
+	for key, pair := range h.Pairs {
+		if !seen[key] {
+			rest = append(rest, pair)
+		}
+	}
+
+	sort.Slice(rest, func(i, j int) bool {
+		return rest[i].Key.Inspect() < rest[j].Key.Inspect()
+	})
+
+	return append(pairs, rest...)
 }
 
 func (h *Hash) Type() ObjectType {
@@ -246,7 +342,7 @@ func (h *Hash) Inspect() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for _, pair := range h.Pairs {
+	for _, pair := range h.OrderedPairs() {
 		pairs = append(pairs, fmt.Sprintf("%s:%s", pair.Key.Inspect(), pair.Value.Inspect()))
 	}
 
@@ -256,3 +352,50 @@ func (h *Hash) Inspect() string {
 
 	return out.String()
 }
+
+// ----------------------------------------------------
+//	Range Struct
+// ----------------------------------------------------
+type Range struct { // Lazy sequence of integers, elements are computed on demand
+	Start int64
+	End   int64 // exclusive
+	Step  int64 // never zero
+}
+
+func (r *Range) Type() ObjectType {
+	return RANGE_OBJ
+}
+
+func (r *Range) Inspect() string {
+	return fmt.Sprintf("xrange(%d, %d, %d)", r.Start, r.End, r.Step)
+}
+
+// Len return how many elements the range produce. The span and step are measured in uint64
+// since `End - Start` can overflow int64, a range longer than math.MaxInt64 report math.MaxInt64
+func (r *Range) Len() int64 {
+	var span, step uint64
+
+	switch {
+	case r.Step > 0 && r.Start < r.End:
+		span, step = uint64(r.End)-uint64(r.Start), uint64(r.Step)
+
+	case r.Step < 0 && r.Start > r.End:
+		span, step = uint64(r.Start)-uint64(r.End), -uint64(r.Step)
+
+	default:
+		return 0
+	}
+
+	count := (span-1)/step + 1
+
+	if count > math.MaxInt64 {
+		return math.MaxInt64
+	}
+
+	return int64(count)
+}
+
+// At return the i-th element of the range
+func (r *Range) At(i int64) int64 {
+	return r.Start + i*r.Step
+}
@@ -1,6 +1,7 @@
 package object
 
 import (
+	"math"
 	"testing"
 )
 
@@ -24,3 +25,35 @@ func TestStringHashKey(t *testing.T) {
 	}
 
 }
+
+func TestRangeLen(t *testing.T) {
+	tests := []struct {
+		r        *Range
+		expected int64
+	}{
+		{&Range{Start: 0, End: 10, Step: 3}, 4},
+		{&Range{Start: 10, End: 0, Step: -3}, 4},
+		{&Range{Start: 5, End: 5, Step: 1}, 0},
+		{&Range{Start: 0, End: 10, Step: -1}, 0},
+		{&Range{Start: 0, End: math.MaxInt64, Step: 2}, 4611686018427387904},
+		{&Range{Start: 0, End: math.MaxInt64, Step: 4611686018427387904}, 2},
+		{&Range{Start: math.MinInt64, End: math.MaxInt64, Step: math.MaxInt64}, 3},
+		{&Range{Start: math.MaxInt64, End: math.MinInt64, Step: math.MinInt64}, 2},
+		{&Range{Start: 0, End: 10, Step: math.MaxInt64}, 1},
+		{&Range{Start: math.MinInt64, End: math.MaxInt64, Step: 1}, math.MaxInt64},
+	}
+
+	for _, tt := range tests {
+		if got := tt.r.Len(); got != tt.expected {
+			t.Errorf("wrong length for %s. want=%d, got=%d", tt.r.Inspect(), tt.expected, got)
+		}
+	}
+
+	r := &Range{Start: math.MinInt64, End: math.MaxInt64, Step: math.MaxInt64}
+
+	for i, expected := range []int64{math.MinInt64, -1, math.MaxInt64 - 1} {
+		if got := r.At(int64(i)); got != expected {
+			t.Errorf("wrong element %d of %s. want=%d, got=%d", i, r.Inspect(), expected, got)
+		}
+	}
+}
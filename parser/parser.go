@@ -11,27 +11,44 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	TERNARY     // a ? b : c
+	PIPE        // |>
 	EQUALS      // ==
 	LESSGREATER // > or <
+	SHIFT       // << or >>
 	SUM         // +
 	PRODUCT     // *
+	POWER       // **
 	PREFIX      // -x or !x
 	CALL        // myFunction()
 	INDEX
 )
 
 var precedences = map[token.TokenType]int{
-	token.ASSIGN:   EQUALS,
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
+	token.QUESTION:   TERNARY,
+	token.PIPE:       PIPE,
+	token.ASSIGN:     EQUALS,
+	token.OR_ASSIGN:  EQUALS,
+	token.AND_ASSIGN: EQUALS,
+	token.EQ:         EQUALS,
+	token.NOT_EQ:     EQUALS,
+	token.LT:         LESSGREATER,
+	token.GT:         LESSGREATER,
+	token.LT_EQ:      LESSGREATER,
+	token.GT_EQ:      LESSGREATER,
+	token.IN:         LESSGREATER,
+	token.NOT:        LESSGREATER,
+	token.SHL:        SHIFT,
+	token.SHR:        SHIFT,
+	token.USHR:       SHIFT,
+	token.PLUS:       SUM,
+	token.MINUS:      SUM,
+	token.SLASH:      PRODUCT,
+	token.ASTERISK:   PRODUCT,
+	token.MODULO:     PRODUCT,
+	token.POW:        POWER,
+	token.LPAREN:     CALL,
+	token.LBRACKET:   INDEX,
 }
 
 type (
@@ -62,12 +79,15 @@ func New(lex *lexer.Lexer) *Parser {
 	parser.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	parser.registerPrefix(token.IDENT, parser.parseIdentifier)
 	parser.registerPrefix(token.INT, parser.parseIntegerLiteral)
+	parser.registerPrefix(token.FLOAT, parser.parseFloatLiteral)
 	parser.registerPrefix(token.BANG, parser.parsePrefixExpression)
 	parser.registerPrefix(token.MINUS, parser.parsePrefixExpression)
 	parser.registerPrefix(token.TRUE, parser.parseBoolean)
 	parser.registerPrefix(token.FALSE, parser.parseBoolean)
+	parser.registerPrefix(token.NULL, parser.parseNullLiteral)
 	parser.registerPrefix(token.LPAREN, parser.parseGroupedExpression)
 	parser.registerPrefix(token.IF, parser.parseIfExpression)
+	parser.registerPrefix(token.WHILE, parser.parseWhileExpression)
 	parser.registerPrefix(token.FUNCTION, parser.parseFunctionLiteral)
 	parser.registerPrefix(token.STRING, parser.parseStringLiteral)
 	parser.registerPrefix(token.LBRACKET, parser.parseArrayLiteral)
@@ -79,11 +99,24 @@ func New(lex *lexer.Lexer) *Parser {
 	parser.registerInfix(token.MINUS, parser.parseInfixExpression)
 	parser.registerInfix(token.SLASH, parser.parseInfixExpression)
 	parser.registerInfix(token.ASTERISK, parser.parseInfixExpression)
+	parser.registerInfix(token.MODULO, parser.parseInfixExpression)
+	parser.registerInfix(token.POW, parser.parseInfixExpression)
 	parser.registerInfix(token.EQ, parser.parseInfixExpression)
 	parser.registerInfix(token.ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.OR_ASSIGN, parser.parseAssignExpression)
+	parser.registerInfix(token.AND_ASSIGN, parser.parseAssignExpression)
 	parser.registerInfix(token.NOT_EQ, parser.parseInfixExpression)
 	parser.registerInfix(token.LT, parser.parseInfixExpression)
 	parser.registerInfix(token.GT, parser.parseInfixExpression)
+	parser.registerInfix(token.LT_EQ, parser.parseInfixExpression)
+	parser.registerInfix(token.GT_EQ, parser.parseInfixExpression)
+	parser.registerInfix(token.SHL, parser.parseInfixExpression)
+	parser.registerInfix(token.SHR, parser.parseInfixExpression)
+	parser.registerInfix(token.USHR, parser.parseInfixExpression)
+	parser.registerInfix(token.IN, parser.parseInfixExpression)
+	parser.registerInfix(token.NOT, parser.parseNotInExpression)
+	parser.registerInfix(token.PIPE, parser.parsePipeExpression)
+	parser.registerInfix(token.QUESTION, parser.parseTernaryExpression)
 	parser.registerInfix(token.LPAREN, parser.parseCallExpression)
 	parser.registerInfix(token.LBRACKET, parser.parseIndexExpression)
 
@@ -95,7 +128,8 @@ func (p *Parser) Errors() []string {
 }
 
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("Expected next token to be %s, but got %s instead", t, p.peekToken.Type)
+	msg := fmt.Sprintf("Expected next token to be %s, but got %s instead at line %d, column %d",
+		t, p.peekToken.Type, p.peekToken.Line, p.peekToken.Column)
 	p.errors = append(p.errors, msg)
 }
 
@@ -131,7 +165,8 @@ func (p *Parser) registerInfix(token token.TokenType, fn infixParseFn) {
 }
 
 func (p *Parser) noPrefixParseFnError(t token.Token) {
-	msg := fmt.Sprintf("no prefix parse function for token %s `%s` found", t.Type, t.Literal)
+	msg := fmt.Sprintf("no prefix parse function for token %s `%s` found at line %d, column %d",
+		t.Type, t.Literal, t.Line, t.Column)
 	p.errors = append(p.errors, msg)
 }
 
@@ -177,6 +212,15 @@ func (p *Parser) parseStatement() ast.Statement {
 	case token.RETURN:
 		return p.parseReturnStatement()
 
+	case token.FOR:
+		return p.parseForStatement()
+
+	case token.BREAK:
+		return p.parseBreakStatement()
+
+	case token.CONTINUE:
+		return p.parseContinueStatement()
+
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -226,6 +270,141 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return stmt
 }
 
+// Dispatch between `for (x in arr) {}` and `for (init; cond; post) {}`
+func (p *Parser) parseForStatement() ast.Statement {
+	tok := p.currToken
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken() // consume the `(`
+
+	if p.curTokenIs(token.IDENT) && (p.peekTokenIs(token.IN) || p.peekTokenIs(token.COMMA)) {
+		return p.parseForInStatement(tok)
+	}
+
+	stmt := &ast.ExpressionStatement{Token: tok}
+	stmt.Expression = p.parseForExpression(tok)
+
+	if stmt.Expression == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// `currToken` sit on the first identifier after the `(`
+func (p *Parser) parseForInStatement(tok token.Token) ast.Statement {
+	stmt := &ast.ForInStatement{Token: tok}
+
+	stmt.Value = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+
+	// Two identifiers. eg: for (i, x in arr) or for (k, v in hash)
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken() // Consume the `,` token
+
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		stmt.Key = stmt.Value
+		stmt.Value = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken() // Consume the `in` token
+
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+
+	// Optional trailing `;` like the C-style loop. eg: for (x in arr) { x };
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// `currToken` sit on the first token after the `(`
+func (p *Parser) parseForExpression(tok token.Token) ast.Expression {
+	exp := &ast.ForExpression{Token: tok}
+
+	// Init statement is optional. eg: for (; i < 10; i = i + 1)
+	if !p.curTokenIs(token.SEMICOLON) {
+		exp.Init = p.parseStatement() // also consume the trailing `;` if there is one
+
+		if !p.curTokenIs(token.SEMICOLON) {
+			p.peekError(token.SEMICOLON)
+			return nil
+		}
+	}
+
+	p.nextToken() // consume the `;`
+
+	// Missing condition loop forever. eg: for (let i = 0; ; i = i + 1)
+	if !p.curTokenIs(token.SEMICOLON) {
+		exp.Condition = p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.SEMICOLON) {
+			return nil
+		}
+	}
+
+	if !p.peekTokenIs(token.RPAREN) {
+		p.nextToken() // consume the `;`
+		exp.Post = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	exp.Body = p.parseBlockStatement()
+
+	return exp
+}
+
+func (p *Parser) parseBreakStatement() *ast.BreakStatement {
+	stmt := &ast.BreakStatement{Token: p.currToken}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseContinueStatement() *ast.ContinueStatement {
+	stmt := &ast.ContinueStatement{Token: p.currToken}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	stmt := &ast.ExpressionStatement{
 		Token: p.currToken,
@@ -294,6 +473,33 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 
 	precedence := p.currPrecedence() // Precedence of the infix operator
 
+	// `**` is right associative, lower the precedence so the right side bind first
+	// 2 ** 3 ** 2 => (2 ** (3 ** 2))
+	if p.curTokenIs(token.POW) {
+		precedence--
+	}
+
+	p.nextToken()
+
+	expr.Right = p.parseExpression(precedence)
+
+	return expr
+}
+
+// x not in arr
+func (p *Parser) parseNotInExpression(left ast.Expression) ast.Expression {
+	expr := &ast.InfixExpression{
+		Token:    p.currToken,
+		Operator: "not in",
+		Left:     left,
+	}
+
+	precedence := p.currPrecedence()
+
+	if !p.expectPeek(token.IN) { // `not` is only valid as part of `not in`
+		return nil
+	}
+
 	p.nextToken()
 
 	expr.Right = p.parseExpression(precedence)
@@ -301,6 +507,37 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expr
 }
 
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	expr := &ast.PipeExpression{Token: p.currToken, Left: left}
+
+	precedence := p.currPrecedence()
+
+	p.nextToken() // consume the `|>` token
+
+	expr.Right = p.parseExpression(precedence)
+
+	return expr
+}
+
+func (p *Parser) parseTernaryExpression(condition ast.Expression) ast.Expression {
+	expr := &ast.TernaryExpression{Token: p.currToken, Condition: condition}
+
+	p.nextToken() // consume the `?` token
+
+	expr.Consequence = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.COLON) {
+		return nil
+	}
+
+	p.nextToken() // consume the `:` token
+
+	// Parse one level lower so a ? b : c ? d : e => (a ? b : (c ? d : e))
+	expr.Alternative = p.parseExpression(TERNARY - 1)
+
+	return expr
+}
+
 func (p *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
 }
@@ -320,6 +557,21 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return literal
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	literal := &ast.FloatLiteral{Token: p.currToken}
+
+	value, err := strconv.ParseFloat(p.currToken.Literal, 64)
+
+	if err != nil {
+		msg := fmt.Sprintf("Could not parse %q as float", p.currToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	literal.Value = value
+	return literal
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	prefixExp := &ast.PrefixExpression{
 		Token:    p.currToken,
@@ -340,13 +592,32 @@ func (p *Parser) parseBoolean() ast.Expression {
 	}
 }
 
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.currToken}
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
 	// If prefix parse function call to `parseExpression` it
 	// have higher precedence and will parse the expression first
+	tok := p.currToken
 	p.nextToken() // consume the `(`
 
 	expression := p.parseExpression(LOWEST)
 
+	// Comma operator. eg: (a = 1, b = 2, a + b)
+	if p.peekTokenIs(token.COMMA) {
+		seq := &ast.SequenceExpression{Token: tok, Expressions: []ast.Expression{expression}}
+
+		for p.peekTokenIs(token.COMMA) {
+			p.nextToken() // Consume the `,` token
+			p.nextToken() // Advance the cursor so it sit on next expression
+
+			seq.Expressions = append(seq.Expressions, p.parseExpression(LOWEST))
+		}
+
+		expression = seq
+	}
+
 	// 2 + (1 + 3) * 4
 	if !p.expectPeek(token.RPAREN) { // consume the `)` cause `parseExpression` doesn't consume it
 		return nil
@@ -388,6 +659,29 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return exp
 }
 
+func (p *Parser) parseWhileExpression() ast.Expression {
+	exp := &ast.WhileExpression{Token: p.currToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken() // advance so `currToken` point to the expression after the `(`
+	exp.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	exp.Body = p.parseBlockStatement()
+
+	return exp
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.currToken}
 	block.Statements = []ast.Statement{}
@@ -416,6 +710,13 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 
 	fun.Parameters = p.parseFunctionParameters()
 
+	// Arrow body. eg: fn(x) => x + 1
+	if p.peekTokenIs(token.ARROW) {
+		p.nextToken() // advance to the `=>` token
+		fun.Body = p.parseArrowBody()
+		return fun
+	}
+
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
@@ -425,6 +726,22 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return fun
 }
 
+// Wrap the single expression after `=>` as a block that return it
+func (p *Parser) parseArrowBody() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: p.currToken}
+
+	p.nextToken() // consume the `=>`
+
+	stmt := &ast.ReturnStatement{
+		Token:       token.Token{Type: token.RETURN, Literal: "return"},
+		ReturnValue: p.parseExpression(LOWEST),
+	}
+
+	block.Statements = []ast.Statement{stmt}
+
+	return block
+}
+
 func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 	idents := []*ast.Identifier{}
 
@@ -530,13 +847,26 @@ func (p *Parser) parseExpressionList(endToken token.TokenType) []ast.Expression
 }
 
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	tok := p.currToken
+
+	// Slice without low bound. eg: arr[:2]
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken() // Sit on the `:` token
+		return p.parseSliceExpression(tok, left, nil)
+	}
 
-	ie := &ast.IndexExpression{Token: p.currToken, Left: left}
+	ie := &ast.IndexExpression{Token: tok, Left: left}
 
 	p.nextToken() // Consume the `[` so we sit on the array index expression
 
 	ie.Index = p.parseExpression(LOWEST)
 
+	// Slice with low bound. eg: arr[1:3] or arr[1:]
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken() // Sit on the `:` token
+		return p.parseSliceExpression(tok, left, ie.Index)
+	}
+
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
@@ -544,10 +874,87 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return ie
 }
 
+// `currToken` sit on the `:` token
+func (p *Parser) parseSliceExpression(tok token.Token, left ast.Expression, low ast.Expression) ast.Expression {
+	se := &ast.SliceExpression{Token: tok, Left: left, Low: low}
+
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken() // Consume the `:` so we sit on the high bound expression
+		se.High = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return se
+}
+
 func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
-	ident := left.(*ast.Identifier)
+	switch left := left.(type) {
+	case *ast.Identifier:
+		expr := &ast.AssignmentExpression{Token: p.currToken, Name: left}
+
+		p.nextToken() // consume the `=` token
+
+		// Parse the right side with the lowest precedence so assignment is right associative
+		// a = b = 0 => (a = (b = 0))
+		expr.Value = p.parseExpression(LOWEST)
+
+		return expr
+
+	case *ast.ArrayLiteral:
+		if p.currToken.Type != token.ASSIGN {
+			msg := fmt.Sprintf("invalid %s target %s", p.currToken.Literal, left.String())
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+
+		return p.parseDestructuringAssignment(left)
+
+	case *ast.IndexExpression:
+		if p.currToken.Type != token.ASSIGN {
+			msg := fmt.Sprintf("invalid %s target %s", p.currToken.Literal, left.String())
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+
+		expr := &ast.IndexAssignmentExpression{Token: p.currToken, Left: left.Left, Index: left.Index}
+
+		p.nextToken() // consume the `=` token
+
+		expr.Value = p.parseExpression(LOWEST)
+
+		return expr
+
+	default:
+		msg := fmt.Sprintf("invalid assignment target %s", left.String())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+}
+
+func (p *Parser) parseDestructuringAssignment(pattern *ast.ArrayLiteral) ast.Expression {
+	da := &ast.DestructuringAssignment{Token: p.currToken}
+
+	// Every element of the pattern must be a plain identifier. eg: [a, b] = [b, a]
+	for _, elem := range pattern.Elements {
+		ident, ok := elem.(*ast.Identifier)
+
+		if !ok {
+			msg := fmt.Sprintf("invalid destructuring target %s", elem.String())
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+
+		da.Names = append(da.Names, ident)
+	}
+
 	p.nextToken() // consume the `=` token
-	return &ast.AssignmentExpression{Token: p.currToken, Name: ident, Value: p.parseExpression(LOWEST)}
+
+	da.Value = p.parseExpression(LOWEST)
+
+	return da
 }
 
 func (p *Parser) parseHashLiteral() ast.Expression {
@@ -569,6 +976,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		hashVal := p.parseExpression(LOWEST)
 
 		hash.Pairs[hashKey] = hashVal
+		hash.Keys = append(hash.Keys, hashKey)
 
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
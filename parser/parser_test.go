@@ -207,6 +207,13 @@ func TestParsingInfixExpression(t *testing.T) {
 		{"5 - 5", 5, "-", 5},
 		{"5 * 5", 5, "*", 5},
 		{"5 / 5", 5, "/", 5},
+		{"5 % 5", 5, "%", 5},
+		{"5 ** 5", 5, "**", 5},
+		{"5 << 5", 5, "<<", 5},
+		{"5 >> 5", 5, ">>", 5},
+		{"5 >>> 5", 5, ">>>", 5},
+		{"5 <= 5", 5, "<=", 5},
+		{"5 >= 5", 5, ">=", 5},
 		{"5 > 5", 5, ">", 5},
 		{"5 < 5", 5, "<", 5},
 		{"5 == 5", 5, "==", 5},
@@ -214,6 +221,8 @@ func TestParsingInfixExpression(t *testing.T) {
 		{"true == true", true, "==", true},
 		{"true != false", true, "!=", false},
 		{"false == false", false, "==", false},
+		{"5 in arr", 5, "in", "arr"},
+		{"5 not in arr", 5, "not in", "arr"},
 	}
 
 	for _, tt := range infixTests {
@@ -273,6 +282,66 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"a + b / c",
 			"(a + (b / c))",
 		},
+		{
+			"a + b % c",
+			"(a + (b % c))",
+		},
+		{
+			"a * b % c",
+			"((a * b) % c)",
+		},
+		{
+			"a = b = c + 1",
+			"(a = (b = (c + 1)))",
+		},
+		{
+			"a[i + 1] = b[0] = c * 2",
+			"(a[(i + 1)] = (b[0] = (c * 2)))",
+		},
+		{
+			"a ? b : c ? d : e",
+			"(a ? b : (c ? d : e))",
+		},
+		{
+			"a == b ? c + 1 : d * 2",
+			"((a == b) ? (c + 1) : (d * 2))",
+		},
+		{
+			"x = a ? b : c",
+			"(x = (a ? b : c))",
+		},
+		{
+			"a ? b ? c : d : e",
+			"(a ? (b ? c : d) : e)",
+		},
+		{
+			"a ||= b &&= c + 1",
+			"(a ||= (b &&= (c + 1)))",
+		},
+		{
+			"a |> f |> g(_, 2)",
+			"((a |> f) |> g(_, 2))",
+		},
+		{
+			"a + b * c |> f",
+			"((a + (b * c)) |> f)",
+		},
+		{
+			"a + b >> c < d",
+			"(((a + b) >> c) < d)",
+		},
+		{
+			"2 ** 3 ** 2",
+			"(2 ** (3 ** 2))",
+		},
+		{
+			"a * b ** c",
+			"(a * (b ** c))",
+		},
+		{
+			"a ** b * c",
+			"((a ** b) * c)",
+		},
 		{
 			"a + b * c + d / e - f",
 			"(((a + (b * c)) + (d / e)) - f)",
@@ -353,6 +422,26 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a + b in c == true",
+			"(((a + b) in c) == true)",
+		},
+		{
+			"!a not in b",
+			"((!a) not in b)",
+		},
+		{
+			"(a, b + c, d)",
+			"(a, (b + c), d)",
+		},
+		{
+			"add((a, b), c)",
+			"add((a, b), c)",
+		},
+		{
+			"[(a, b), c]",
+			"[(a, b), c]",
+		},
 	}
 
 	for _, tt := range tests {
@@ -880,6 +969,461 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 	}
 }
 
+func TestDestructuringAssignmentParsing(t *testing.T) {
+	input := `[a, b] = [b, a];`
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	da, ok := stmt.Expression.(*ast.DestructuringAssignment)
+
+	if !ok {
+		t.Fatalf("exp not *ast.DestructuringAssignment. got=%T", stmt.Expression)
+	}
+
+	if len(da.Names) != 2 {
+		t.Fatalf("wrong number of names. want=%d, got=%d", 2, len(da.Names))
+	}
+
+	testIdentifier(t, da.Names[0], "a")
+	testIdentifier(t, da.Names[1], "b")
+
+	if da.Value.String() != "[b, a]" {
+		t.Errorf("da.Value.String() wrong. want=%q, got=%q", "[b, a]", da.Value.String())
+	}
+}
+
+func TestInvalidAssignmentTarget(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"5 = 1", "invalid assignment target 5"},
+		{"[a, 1] = [1, 2]", "invalid destructuring target 1"},
+		{"[a, b] ||= [1, 2]", "invalid ||= target [a, b]"},
+		{"a[0] &&= 1", "invalid &&= target (a[0])"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expectedError {
+			t.Errorf("wrong parser errors. want first=%q, got=%q", tt.expectedError, errors)
+		}
+	}
+}
+
+func TestForInStatementParsing(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedKey      string
+		expectedValue    string
+		expectedIterable string
+	}{
+		{"for (x in arr) { x }", "", "x", "arr"},
+		{"for (k, v in hash) { v }", "k", "v", "hash"},
+		{"for (x in [1, 2]) { break; continue; }", "", "x", "[1, 2]"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d", 1, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ForInStatement)
+
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ForInStatement. got=%T", program.Statements[0])
+		}
+
+		if tt.expectedKey == "" && stmt.Key != nil {
+			t.Errorf("stmt.Key was not nil. got=%+v", stmt.Key)
+		}
+
+		if tt.expectedKey != "" && !testIdentifier(t, stmt.Key, tt.expectedKey) {
+			return
+		}
+
+		if !testIdentifier(t, stmt.Value, tt.expectedValue) {
+			return
+		}
+
+		if stmt.Iterable.String() != tt.expectedIterable {
+			t.Errorf("stmt.Iterable.String() wrong. want=%q, got=%q", tt.expectedIterable, stmt.Iterable.String())
+		}
+	}
+}
+
+func TestForInTrailingSemicolon(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedStatements int
+	}{
+		{"for (x in [1, 2]) { x }; r", 2},
+		{"for (k, v in h) { v };", 1},
+		{"let r = 0; for (x in xs) { r = r + x }; r;", 3},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != tt.expectedStatements {
+			t.Errorf("wrong number of statements for %q. want=%d, got=%d",
+				tt.input, tt.expectedStatements, len(program.Statements))
+		}
+	}
+}
+
+func TestArrowFunctionParsing(t *testing.T) {
+	input := `fn(x, y) => x + y`
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d", 1, len(program.Statements))
+	}
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	function, ok := stmt.Expression.(*ast.FunctionLiteral)
+
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.FunctionLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(function.Parameters) != 2 {
+		t.Fatalf("function literal parameters wrong. want 2, got=%d", len(function.Parameters))
+	}
+
+	if len(function.Body.Statements) != 1 {
+		t.Fatalf("function.Body.Statements has not 1 statements. got=%d", len(function.Body.Statements))
+	}
+
+	ret, ok := function.Body.Statements[0].(*ast.ReturnStatement)
+
+	if !ok {
+		t.Fatalf("function body stmt is not ast.ReturnStatement. got=%T", function.Body.Statements[0])
+	}
+
+	testInfixExpression(t, ret.ReturnValue, "x", "+", "y")
+}
+
+func TestWhileExpression(t *testing.T) {
+	input := `while (x < y) { x }`
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	exp, ok := stmt.Expression.(*ast.WhileExpression)
+
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.WhileExpression. got=%T", stmt.Expression)
+	}
+
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(exp.Body.Statements) != 1 {
+		t.Fatalf("body is not 1 statements. got=%d", len(exp.Body.Statements))
+	}
+
+	body, ok := exp.Body.Statements[0].(*ast.ExpressionStatement)
+
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T", exp.Body.Statements[0])
+	}
+
+	testIdentifier(t, body.Expression, "x")
+}
+
+func TestForExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{"for (let i = 0; i < 10; i = i + 1) { i }", "for (let i = 0; (i < 10); (i = (i + 1))) i"},
+		{"for (i = 0; i < 10; i = i + 1) { i }", "for ((i = 0); (i < 10); (i = (i + 1))) i"},
+		{"for (; i < 10;) { i }", "for (; (i < 10); ) i"},
+		{"for (;;) { break; }", "for (; ; ) break;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d", 1, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		if _, ok := stmt.Expression.(*ast.ForExpression); !ok {
+			t.Fatalf("stmt.Expression is not ast.ForExpression. got=%T", stmt.Expression)
+		}
+
+		if program.String() != tt.expectedOutput {
+			t.Errorf("program.String() wrong. want=%q, got=%q", tt.expectedOutput, program.String())
+		}
+	}
+}
+
+func TestBreakContinueParsing(t *testing.T) {
+	input := `while (true) { break; continue }`
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	body := stmt.Expression.(*ast.WhileExpression).Body
+
+	if len(body.Statements) != 2 {
+		t.Fatalf("body does not contain %d statements. got=%d", 2, len(body.Statements))
+	}
+
+	if _, ok := body.Statements[0].(*ast.BreakStatement); !ok {
+		t.Errorf("body.Statements[0] is not ast.BreakStatement. got=%T", body.Statements[0])
+	}
+
+	if _, ok := body.Statements[1].(*ast.ContinueStatement); !ok {
+		t.Errorf("body.Statements[1] is not ast.ContinueStatement. got=%T", body.Statements[1])
+	}
+}
+
+func TestParsingSliceExpression(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedLow  interface{}
+		expectedHigh interface{}
+	}{
+		{"arr[1:3]", 1, 3},
+		{"arr[:2]", nil, 2},
+		{"arr[1:]", 1, nil},
+		{"arr[:]", nil, nil},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		stmt, _ := program.Statements[0].(*ast.ExpressionStatement)
+		slice, ok := stmt.Expression.(*ast.SliceExpression)
+
+		if !ok {
+			t.Fatalf("exp not *ast.SliceExpression. got=%T", stmt.Expression)
+		}
+
+		if !testIdentifier(t, slice.Left, "arr") {
+			return
+		}
+
+		if tt.expectedLow == nil && slice.Low != nil {
+			t.Errorf("slice.Low is not nil. got=%s", slice.Low.String())
+		} else if tt.expectedLow != nil {
+			testLiteralExpression(t, slice.Low, tt.expectedLow)
+		}
+
+		if tt.expectedHigh == nil && slice.High != nil {
+			t.Errorf("slice.High is not nil. got=%s", slice.High.String())
+		} else if tt.expectedHigh != nil {
+			testLiteralExpression(t, slice.High, tt.expectedHigh)
+		}
+	}
+}
+
+func TestParserErrorPositions(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"let x = 1;\nlet = 5;", "Expected next token to be IDENT, but got ASSIGN instead at line 2, column 5"},
+		{"let x = 1;\n  let y = );", "no prefix parse function for token RPAREN `)` found at line 2, column 11"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expectedError {
+			t.Errorf("wrong parser errors. want first=%q, got=%q", tt.expectedError, errors)
+		}
+	}
+}
+
+func TestNullLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"null;", "null"},
+		{"nil;", "nil"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program should have %d statements. got=%d", 1, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		null, ok := stmt.Expression.(*ast.NullLiteral)
+
+		if !ok {
+			t.Fatalf("exp not *ast.NullLiteral. got=%T", stmt.Expression)
+		}
+
+		if null.TokenLiteral() != tt.expected {
+			t.Errorf("null.TokenLiteral not %q. got=%q", tt.expected, null.TokenLiteral())
+		}
+	}
+}
+
+func TestHashLiteralKeepsKeyOrder(t *testing.T) {
+	l := lexer.New(`{"c": 1, "a": 2, "b": 3}`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	expected := []string{"c", "a", "b"}
+
+	if len(hash.Keys) != len(expected) {
+		t.Fatalf("hash.Keys has wrong length. want=%d, got=%d", len(expected), len(hash.Keys))
+	}
+
+	for i, key := range hash.Keys {
+		if key.String() != expected[i] {
+			t.Errorf("hash.Keys[%d] wrong. want=%q, got=%q", i, expected[i], key.String())
+		}
+	}
+}
+
+func TestDigitSeparatorParsing(t *testing.T) {
+	l := lexer.New("1_000_000;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	testIntegerLiteral(t, stmt.Expression, 1000000)
+
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"let x = 1__000;", "no prefix parse function for token ILLEGAL `1__000` found at line 1, column 9"},
+		{"1000_ + 1;", "no prefix parse function for token ILLEGAL `1000_` found at line 1, column 1"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+
+		if len(errors) == 0 || errors[0] != tt.expectedError {
+			t.Errorf("wrong parser errors. want first=%q, got=%q", tt.expectedError, errors)
+		}
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	l := lexer.New("3.14;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+
+	if literal.Value != 3.14 {
+		t.Errorf("literal.Value not %f. got=%f", 3.14, literal.Value)
+	}
+
+	if literal.TokenLiteral() != "3.14" {
+		t.Errorf("literal.TokenLiteral not %s. got=%s", "3.14", literal.TokenLiteral())
+	}
+}
+
 // #########################################
 // Private method
 // #########################################
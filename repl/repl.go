@@ -8,6 +8,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"strings"
 )
 
 const PROMPT = ">> "
@@ -29,9 +30,10 @@ const MONKEY_FACE = `
 func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
 	env := object.NewEnvironment()
+	showTypes := false
 
 	for {
-		fmt.Print(PROMPT)
+		fmt.Fprint(out, PROMPT)
 
 		scanned := scanner.Scan()
 
@@ -40,6 +42,24 @@ func Start(in io.Reader, out io.Writer) {
 		}
 
 		line := scanner.Text()
+
+		// REPL commands. eg: `:types on` print the type alongside each value
+		if strings.HasPrefix(line, ":") {
+			switch strings.TrimSpace(line) {
+			case ":types on":
+				showTypes = true
+			case ":types off":
+				showTypes = false
+			case ":trace on":
+				evaluator.Trace, evaluator.TraceOut = true, out
+			case ":trace off":
+				evaluator.Trace = false
+			default:
+				io.WriteString(out, "unknown command: "+line+"\n")
+			}
+			continue
+		}
+
 		l := lexer.New(line)
 		p := parser.New(l)
 
@@ -54,6 +74,11 @@ func Start(in io.Reader, out io.Writer) {
 
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
+
+			if showTypes {
+				io.WriteString(out, " : "+string(evaluated.Type()))
+			}
+
 			io.WriteString(out, "\n")
 		}
 
@@ -0,0 +1,74 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTypesToggle(t *testing.T) {
+	input := strings.Join([]string{
+		`5`,
+		`:types on`,
+		`5`,
+		`"monkey"`,
+		`[1, 2]`,
+		`:types off`,
+		`5`,
+	}, "\n")
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	expected := []string{
+		"5",
+		"5 : INTEGER",
+		"monkey : STRING",
+		"[1, 2] : ARRAY",
+		"5",
+	}
+
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(out.String(), PROMPT, "")), "\n")
+
+	if len(lines) != len(expected) {
+		t.Fatalf("wrong number of output lines. want=%d, got=%d (%q)", len(expected), len(lines), lines)
+	}
+
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("line[%d] wrong. want=%q, got=%q", i, expected[i], line)
+		}
+	}
+}
+
+func TestTraceToggle(t *testing.T) {
+	input := strings.Join([]string{
+		`:trace on`,
+		`5`,
+		`:trace off`,
+		`6`,
+	}, "\n")
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	expected := []string{
+		"Program",
+		"  ExpressionStatement",
+		"    IntegerLiteral",
+		"5",
+		"6",
+	}
+
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(out.String(), PROMPT, "")), "\n")
+
+	if len(lines) != len(expected) {
+		t.Fatalf("wrong number of output lines. want=%d, got=%d (%q)", len(expected), len(lines), lines)
+	}
+
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("line[%d] wrong. want=%q, got=%q", i, expected[i], line)
+		}
+	}
+}
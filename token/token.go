@@ -5,6 +5,8 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	Line    int // 1-based line of the first character of the token
+	Column  int // 1-based column of the first character of the token
 }
 
 const (
@@ -14,6 +16,7 @@ const (
 	// Identifiers + literals
 	IDENT = "IDENT"
 	INT   = "INT"
+	FLOAT = "FLOAT"
 
 	// Operators
 	ASSIGN = "ASSIGN" // `=`
@@ -23,10 +26,23 @@ const (
 	MINUS    = "MINUS"    //`-`
 	BANG     = "BANG"     // `!`
 	ASTERISK = "ASTERISK" // `*`
+	POW      = "POW"      // `**`
 	SLASH    = "SLASH"    // `/`
+	MODULO   = "MODULO"   // `%`
 	LT       = "LT"       // `>`
 	GT       = "GT"       // `<`
+	LT_EQ    = "LT_EQ"    // `<=`
+	GT_EQ    = "GT_EQ"    // `>=`
 	COLON    = "COLON"    // `:`
+	QUESTION = "QUESTION" // `?`
+	SHL      = "SHL"      // `<<`
+	SHR      = "SHR"      // `>>`
+	USHR     = "USHR"     // `>>>`
+	ARROW    = "ARROW"    // `=>`
+	PIPE     = "PIPE"     // `|>`
+
+	OR_ASSIGN  = "OR_ASSIGN"  // `||=`
+	AND_ASSIGN = "AND_ASSIGN" // `&&=`
 
 	// Delimiters
 	COMMA     = "COMMA"     // `,`
@@ -48,22 +64,37 @@ const (
 	LET      = "LET"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
+	NULL     = "NULL"
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	NOT      = "NOT"
 
 	// String
 	STRING = "STRING"
 )
 
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"true":     TRUE,
+	"false":    FALSE,
+	"null":     NULL,
+	"nil":      NULL,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"not":      NOT,
 }
 
 func LookupIdent(ident string) TokenType {